@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// Import path of the Gomuti package that generated doubles depend upon.
+const typesPath = "github.com/xeger/gomuti/types"
+
+// generator accumulates source code for one or more test doubles, keeping
+// track of the packages that the code needs to import.
+type generator struct {
+	// import path and name of the package that will contain the generated code
+	pkgPath, pkgName string
+	// prefix for the names of generated types
+	prefix string
+
+	// import path -> local name, and vice versa
+	imports map[string]string
+	names   map[string]string
+
+	body bytes.Buffer
+}
+
+func newGenerator(pkgPath, pkgName, prefix string) *generator {
+	g := &generator{
+		pkgPath: pkgPath,
+		pkgName: pkgName,
+		prefix:  prefix,
+		imports: map[string]string{},
+		names:   map[string]string{},
+	}
+	g.importName(typesPath, "types")
+	return g
+}
+
+// Returns the local name by which the generated code should refer to a
+// package, adding it to the imports if necessary. Chooses a unique alias if
+// two imported packages have the same name.
+func (g *generator) importName(path, name string) string {
+	if alias, ok := g.imports[path]; ok {
+		return alias
+	}
+	alias := name
+	for i := 2; g.names[alias] != ""; i++ {
+		alias = fmt.Sprintf("%s%d", name, i)
+	}
+	g.imports[path] = alias
+	g.names[alias] = path
+	return alias
+}
+
+// Qualifier for types.TypeString; omits the package name of types that live
+// in the package being generated.
+func (g *generator) qualifier(p *types.Package) string {
+	if p.Path() == g.pkgPath {
+		return ""
+	}
+	return g.importName(p.Path(), p.Name())
+}
+
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+// Generates a test double for a named interface type.
+func (g *generator) generate(named *types.Named) error {
+	obj := named.Obj()
+	iface, ok := named.Underlying().(*types.Interface)
+	if !ok {
+		return fmt.Errorf("%s is not an interface", obj.Name())
+	} else if !iface.IsMethodSet() {
+		return fmt.Errorf("%s is a type constraint, not an ordinary interface", obj.Name())
+	}
+
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		if !m.Exported() && m.Pkg() != nil && m.Pkg().Path() != g.pkgPath {
+			return fmt.Errorf("%s has unexported method %s, which cannot be implemented outside package %s", obj.Name(), m.Name(), m.Pkg().Path())
+		}
+	}
+
+	double := g.prefix + obj.Name()
+	tparams, targs := g.typeParams(named.TypeParams())
+	recv := double + targs
+	gomuti := g.imports[typesPath]
+
+	fmt.Fprintf(&g.body, "// %s is a test double for %s.\n", double, obj.Name())
+	fmt.Fprintf(&g.body, "type %s%s struct {\n", double, tparams)
+	iname := obj.Name() + targs
+	if q := g.qualifier(obj.Pkg()); q != "" {
+		iname = q + "." + iname
+	}
+	fmt.Fprintf(&g.body, "\tMock     %s.Mock\n\tSpy      %s.Spy\n\tStub     bool\n\tDelegate %s\n}\n\n", gomuti, gomuti, iname)
+
+	if named.TypeParams().Len() == 0 {
+		fmt.Fprintf(&g.body, "var _ %s = (*%s)(nil)\n\n", g.typeString(named), double)
+	}
+
+	for i := 0; i < iface.NumMethods(); i++ {
+		g.method(double, recv, iface.Method(i))
+	}
+	return nil
+}
+
+// Returns the declaration of a list of type parameters (e.g. "[K comparable,
+// V any]") and the corresponding list of type arguments (e.g. "[K, V]").
+func (g *generator) typeParams(list *types.TypeParamList) (string, string) {
+	if list.Len() == 0 {
+		return "", ""
+	}
+	decl := make([]string, list.Len())
+	args := make([]string, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		tp := list.At(i)
+		args[i] = tp.Obj().Name()
+		decl[i] = args[i] + " " + g.typeString(tp.Constraint())
+	}
+	return "[" + strings.Join(decl, ", ") + "]", "[" + strings.Join(args, ", ") + "]"
+}
+
+// Generates a method that records the call with the double's Spy, then
+// consults its Mock for the results.
+func (g *generator) method(double, recv string, m *types.Func) {
+	sig := m.Type().(*types.Signature)
+
+	results := make([]string, sig.Results().Len())
+	for i := range results {
+		results[i] = g.typeString(sig.Results().At(i).Type())
+	}
+	ret := strings.Join(results, ", ")
+	if len(results) > 1 {
+		ret = "(" + ret + ")"
+	}
+
+	params := g.paramNames(sig.Params())
+
+	decls := make([]string, len(params))
+	for i, p := range params {
+		t := sig.Params().At(i).Type()
+		if sig.Variadic() && i == len(params)-1 {
+			decls[i] = p + " ..." + g.typeString(t.(*types.Slice).Elem())
+		} else {
+			decls[i] = p + " " + g.typeString(t)
+		}
+	}
+
+	args := append([]string{fmt.Sprintf("%q", m.Name())}, params...)
+	gomuti := g.imports[typesPath]
+
+	b := &g.body
+	fmt.Fprintf(b, "// %s records the call and its outcome, and returns the behavior programmed into the Mock.\n", m.Name())
+	fmt.Fprintf(b, "func (_m *%s) %s(%s) %s {\n", recv, m.Name(), strings.Join(decls, ", "), ret)
+	fmt.Fprintf(b, "\t_c := _m.Spy.Init().Begin(%s)\n", strings.Join(args, ", "))
+	fmt.Fprintf(b, "\tdefer _c.Recover()\n")
+	fmt.Fprintf(b, "\t_r := _m.Mock.Call(%s)\n", strings.Join(args, ", "))
+	fmt.Fprintf(b, "\tif _r == nil && _m.Delegate != nil {\n")
+	fmt.Fprintf(b, "\t\t_r = %s.CallThrough(_m.Delegate, %s)\n\t}\n", gomuti, strings.Join(args, ", "))
+	fmt.Fprintf(b, "\tif _r == nil && !_m.Stub {\n")
+	fmt.Fprintf(b, "\t\t_e := _m.Mock.Explain(%s)\n", strings.Join(args, ", "))
+	fmt.Fprintf(b, "\t\t_e.Double = %q\n\t\tpanic(_e)\n\t}\n", double)
+	names := make([]string, len(results))
+	for i, r := range results {
+		names[i] = fmt.Sprintf("_r%d", i)
+		fmt.Fprintf(b, "\tvar _r%d %s\n", i, r)
+		fmt.Fprintf(b, "\tif len(_r) > %d && _r[%d] != nil {\n\t\t_r%d = _r[%d].(%s)\n\t}\n", i, i, i, i, r)
+	}
+	fmt.Fprintf(b, "\t_c.Return(%s)\n", strings.Join(names, ", "))
+	if len(results) > 0 {
+		fmt.Fprintf(b, "\treturn %s\n", strings.Join(names, ", "))
+	}
+	fmt.Fprintf(b, "}\n\n")
+}
+
+// Chooses names for the parameters of a method: the names from the interface
+// declaration where possible, or positional names where the declared name is
+// missing, blank, duplicated or would shadow an imported package. The types
+// of the method's results must already have been resolved.
+func (g *generator) paramNames(tuple *types.Tuple) []string {
+	// Resolve every type first so that names of imported packages are known.
+	for i := 0; i < tuple.Len(); i++ {
+		g.typeString(tuple.At(i).Type())
+	}
+
+	names := make([]string, tuple.Len())
+	seen := map[string]bool{}
+	for i := range names {
+		n := tuple.At(i).Name()
+		if n == "" || n == "_" || strings.HasPrefix(n, "_") || seen[n] || g.names[n] != "" {
+			n = fmt.Sprintf("_p%d", i)
+		}
+		seen[n] = true
+		names[i] = n
+	}
+	return names
+}
+
+// Returns the complete, gofmt-ed source file.
+func (g *generator) source() ([]byte, error) {
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "// Code generated by gomuti-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(b, "package %s\n\n", g.pkgName)
+
+	// Standard library imports come first, then everything else.
+	var std, other []string
+	for path := range g.imports {
+		if strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	b.WriteString("import (\n")
+	for i, paths := range [][]string{std, other} {
+		if i > 0 && len(std) > 0 {
+			b.WriteString("\n")
+		}
+		for _, path := range paths {
+			alias := g.imports[path]
+			if alias == path[strings.LastIndex(path, "/")+1:] {
+				fmt.Fprintf(b, "\t%q\n", path)
+			} else {
+				fmt.Fprintf(b, "\t%s %q\n", alias, path)
+			}
+		}
+	}
+	b.WriteString(")\n\n")
+	b.Write(g.body.Bytes())
+
+	return format.Source(b.Bytes())
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const fixture = `
+package shapes
+
+import "io"
+
+type Sizer interface {
+	Size() (w, h int, err error)
+}
+
+type Shape interface {
+	Sizer
+	io.Closer
+	Draw(canvas io.Writer, layers ...string)
+	Name(_ int, types string) string
+}
+
+type Store[K comparable, V any] interface {
+	Get(key K) (V, bool)
+	Put(key K, value V)
+	PutAll(values ...V) error
+}
+
+type Number interface {
+	~int | ~float64
+}
+
+type sealed interface {
+	seal()
+}
+`
+
+// Type-checks the fixture and returns the named type with the given name.
+func lookup(name string) *types.Named {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "shapes.go", fixture, 0)
+	Expect(err).NotTo(HaveOccurred())
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("example.com/shapes", fset, []*ast.File{f}, nil)
+	Expect(err).NotTo(HaveOccurred())
+	return pkg.Scope().Lookup(name).Type().(*types.Named)
+}
+
+// Type-checks generated source. If pkgPath is set, the source belongs to the
+// fixture's package; otherwise it is a package of its own that imports the
+// fixture. Extra files may use the generated doubles.
+func typeCheck(pkgPath, src string, extra ...string) error {
+	fset := token.NewFileSet()
+	parse := func(name, src string) *ast.File {
+		f, err := parser.ParseFile(fset, name, src, 0)
+		Expect(err).NotTo(HaveOccurred())
+		return f
+	}
+	files := []*ast.File{parse("mocks.go", src)}
+	for i, e := range extra {
+		files = append(files, parse(fmt.Sprintf("extra%d.go", i), e))
+	}
+	shapes := parse("shapes.go", fixture)
+	source := importer.ForCompiler(fset, "source", nil)
+	conf := types.Config{Importer: source}
+	if pkgPath != "" {
+		_, err := conf.Check(pkgPath, fset, append(files, shapes), nil)
+		return err
+	}
+
+	pkg, err := conf.Check("example.com/shapes", fset, []*ast.File{shapes}, nil)
+	Expect(err).NotTo(HaveOccurred())
+	conf.Importer = importerFunc(func(path string) (*types.Package, error) {
+		if path == pkg.Path() {
+			return pkg, nil
+		}
+		return source.Import(path)
+	})
+	_, err = conf.Check("example.com/shapes_test", fset, files, nil)
+	return err
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+func generate(pkgPath, pkgName string, names ...string) string {
+	g := newGenerator(pkgPath, pkgName, "Mock")
+	for _, n := range names {
+		Expect(g.generate(lookup(n))).To(Succeed())
+	}
+	src, err := g.source()
+	Expect(err).NotTo(HaveOccurred())
+	return string(src)
+}
+
+var _ = Describe("generator", func() {
+	It("generates a double with Mock, Spy, Stub and Delegate fields", func() {
+		src := generate("example.com/shapes", "shapes", "Sizer")
+		Expect(src).To(HavePrefix("// Code generated by gomuti-gen. DO NOT EDIT."))
+		Expect(src).To(ContainSubstring(`"github.com/xeger/gomuti/types"`))
+		Expect(src).To(MatchRegexp(`type MockSizer struct {\s+Mock\s+types.Mock\s+Spy\s+types.Spy\s+Stub\s+bool\s+Delegate\s+Sizer\s+}`))
+		Expect(src).To(ContainSubstring("var _ Sizer = (*MockSizer)(nil)"))
+		Expect(src).To(ContainSubstring("func (_m *MockSizer) Size() (int, int, error) {"))
+		Expect(src).To(ContainSubstring("_c := _m.Spy.Init().Begin(\"Size\")\n\tdefer _c.Recover()"))
+		Expect(src).To(ContainSubstring("_c.Return(_r0, _r1, _r2)\n\treturn _r0, _r1, _r2"))
+		Expect(src).To(ContainSubstring(`_r := _m.Mock.Call("Size")`))
+		Expect(src).To(ContainSubstring("if len(_r) > 2 && _r[2] != nil {\n\t\t_r2 = _r[2].(error)\n\t}"))
+	})
+
+	It("includes methods of embedded interfaces", func() {
+		src := generate("example.com/shapes", "shapes", "Shape")
+		Expect(src).To(ContainSubstring("func (_m *MockShape) Size() (int, int, error) {"))
+		Expect(src).To(ContainSubstring("func (_m *MockShape) Close() error {"))
+		Expect(src).To(ContainSubstring(`"io"`))
+	})
+
+	It("handles variadic and awkwardly-named parameters", func() {
+		src := generate("example.com/shapes", "shapes", "Shape")
+		Expect(src).To(ContainSubstring("func (_m *MockShape) Draw(canvas io.Writer, layers ...string) {"))
+		Expect(src).To(ContainSubstring(`_r := _m.Mock.Call("Draw", canvas, layers)`))
+		Expect(src).To(ContainSubstring("if _r == nil && _m.Delegate != nil {\n\t\t_r = types.CallThrough(_m.Delegate, \"Draw\", canvas, layers)\n\t}"))
+		Expect(src).To(ContainSubstring("_e := _m.Mock.Explain(\"Draw\", canvas, layers)\n\t\t_e.Double = \"MockShape\"\n\t\tpanic(_e)"))
+		Expect(src).To(ContainSubstring("func (_m *MockShape) Name(_p0 int, _p1 string) string {"))
+	})
+
+	It("generates generic doubles for generic interfaces", func() {
+		src := generate("example.com/shapes", "shapes", "Store")
+		Expect(src).To(ContainSubstring("type MockStore[K comparable, V any] struct {"))
+		Expect(src).To(MatchRegexp(`Delegate\s+Store\[K, V\]`))
+		Expect(src).To(ContainSubstring("func (_m *MockStore[K, V]) Get(key K) (V, bool) {"))
+		Expect(src).To(ContainSubstring("_r0 = _r[0].(V)"))
+		Expect(src).NotTo(ContainSubstring("var _ Store"))
+	})
+
+	It("qualifies types when generating into another package", func() {
+		src := generate("", "shapes_test", "Sizer")
+		Expect(src).To(ContainSubstring("package shapes_test"))
+		Expect(src).To(ContainSubstring(`"example.com/shapes"`))
+		Expect(src).To(ContainSubstring("var _ shapes.Sizer = (*MockSizer)(nil)"))
+		Expect(src).To(MatchRegexp(`Delegate\s+shapes.Sizer`))
+	})
+
+	It("generates code that compiles against package types", func() {
+		src := generate("example.com/shapes", "shapes", "Sizer", "Shape", "Store")
+		Expect(typeCheck("example.com/shapes", src,
+			"package shapes\n\nvar _ Store[string, int] = (*MockStore[string, int])(nil)\n")).To(Succeed())
+
+		src = generate("", "shapes_test", "Shape", "Store")
+		Expect(typeCheck("", src,
+			"package shapes_test\n\nimport \"example.com/shapes\"\n\nvar _ shapes.Store[int, []byte] = (*MockStore[int, []byte])(nil)\n")).To(Succeed())
+	})
+
+	It("refuses to generate doubles it cannot implement", func() {
+		g := newGenerator("", "shapes_test", "Mock")
+		Expect(g.generate(lookup("Number"))).NotTo(Succeed())
+		Expect(g.generate(lookup("sealed"))).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,134 @@
+// Command gomuti-gen generates Gomuti test doubles for Go interfaces. Each
+// double is a struct with Mock, Spy and Stub fields whose methods record
+// calls with the Spy and then consult the Mock for results, exactly as
+// described in the documentation of package gomuti.
+//
+// Usage:
+//
+//     gomuti-gen [flags] package Interface [Interface ...]
+//
+// The package may be any pattern understood by the go tool: a relative
+// directory such as ".", or an import path from the current module or its
+// dependencies. Generic interfaces produce generic doubles, and methods of
+// embedded interfaces are included.
+//
+// Flags:
+//
+//     -o file     write the generated code to file instead of stdout
+//     -pkg name   package name of the generated code (default: the name of
+//                 the interface's package if generating into its directory)
+//     -prefix p   prefix for the names of generated types (default "Mock")
+//
+// Typical use is via a go:generate directive next to the interface:
+//
+//     //go:generate gomuti-gen -o mock_adder_test.go . Adder
+//
+// Unless its Stub field is true, a generated double panics when a method
+// call matches no programmed behavior; if Stub is true, it returns zero
+// values instead.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func main() {
+	output := flag.String("o", "", "output file (default stdout)")
+	pkgName := flag.String("pkg", "", "package name of the generated code")
+	prefix := flag.String("prefix", "Mock", "prefix for the names of generated types")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: gomuti-gen [flags] package Interface [Interface ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), flag.Args()[1:], *output, *pkgName, *prefix); err != nil {
+		fmt.Fprintf(os.Stderr, "gomuti-gen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(pattern string, names []string, output, pkgName, prefix string) error {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedTypes | packages.NeedImports | packages.NeedDeps,
+	}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return err
+	} else if len(pkgs) != 1 {
+		return fmt.Errorf("pattern %q matched %d packages; expected exactly one", pattern, len(pkgs))
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return pkg.Errors[0]
+	}
+
+	pkgPath, pkgName, err := destination(pkg, output, pkgName)
+	if err != nil {
+		return err
+	}
+
+	g := newGenerator(pkgPath, pkgName, prefix)
+	for _, name := range names {
+		obj := pkg.Types.Scope().Lookup(name)
+		if obj == nil {
+			return fmt.Errorf("%s.%s not found", pkg.PkgPath, name)
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok {
+			return fmt.Errorf("%s.%s is not a named type", pkg.PkgPath, name)
+		}
+		if err := g.generate(named); err != nil {
+			return err
+		}
+	}
+
+	src, err := g.source()
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(output, src, 0644)
+}
+
+// Determines the import path and name of the package that will contain the
+// generated code. When generating into the directory of the interface's own
+// package (with the same package name), types from that package need no
+// qualification; otherwise, the generated code imports the package.
+func destination(pkg *packages.Package, output, pkgName string) (string, string, error) {
+	outDir, err := filepath.Abs(filepath.Dir(output))
+	if err != nil {
+		return "", "", err
+	}
+
+	sameDir := false
+	if len(pkg.GoFiles) > 0 {
+		sameDir = filepath.Dir(pkg.GoFiles[0]) == outDir
+	}
+
+	switch {
+	case pkgName == "" && sameDir:
+		return pkg.PkgPath, pkg.Name, nil
+	case pkgName == "":
+		return "", "", fmt.Errorf("must specify -pkg when generating outside the directory of %s", pkg.PkgPath)
+	case sameDir && pkgName == pkg.Name:
+		return pkg.PkgPath, pkgName, nil
+	default:
+		// A different package, e.g. an external test package; its path never
+		// matches that of an imported type.
+		return "", pkgName, nil
+	}
+}
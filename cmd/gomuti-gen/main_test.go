@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/go/packages"
+)
+
+const shapes = `package shapes
+
+import (
+	"io"
+
+	"github.com/xeger/gomuti/types"
+)
+
+type Shape interface {
+	io.Closer
+	Draw(canvas io.Writer, layers ...string)
+}
+
+type Timer interface {
+	types.Clock
+	Stop() bool
+}
+`
+
+var _ = Describe("run", func() {
+	var wd, dir string
+
+	// Writes a file into dir.
+	write := func(name, src string) {
+		Expect(os.WriteFile(filepath.Join(dir, name), []byte(src), 0644)).To(Succeed())
+	}
+
+	// Loads the package matching pattern and returns its errors.
+	check := func(pattern string) []packages.Error {
+		cfg := &packages.Config{Mode: packages.NeedName | packages.NeedTypes}
+		pkgs, err := packages.Load(cfg, pattern)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pkgs).To(HaveLen(1))
+		return pkgs[0].Errors
+	}
+
+	// Sets up a module of its own that uses this one, and makes it the working
+	// directory, as go:generate would.
+	BeforeEach(func() {
+		var err error
+		wd, err = os.Getwd()
+		Expect(err).NotTo(HaveOccurred())
+		root, err := filepath.Abs(filepath.Join("..", ".."))
+		Expect(err).NotTo(HaveOccurred())
+		dir, err = os.MkdirTemp("", "gomuti-gen")
+		Expect(err).NotTo(HaveOccurred())
+
+		write("go.mod", fmt.Sprintf("module example.com/shapes\n\ngo 1.21\n\n"+
+			"require github.com/xeger/gomuti v0.0.0\n\n"+
+			"replace github.com/xeger/gomuti => %s\n", root))
+		if sum, err := os.ReadFile(filepath.Join(root, "go.sum")); err == nil {
+			write("go.sum", string(sum))
+		}
+		write("shapes.go", shapes)
+		Expect(os.Mkdir(filepath.Join(dir, "fakes"), 0755)).To(Succeed())
+		Expect(os.Chdir(dir)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.Chdir(wd)).To(Succeed())
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("writes doubles into the interface's package", func() {
+		Expect(run(".", []string{"Shape", "Timer"}, "mock_shapes.go", "", "Mock")).To(Succeed())
+		src, err := os.ReadFile(filepath.Join(dir, "mock_shapes.go"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(src)).To(ContainSubstring("package shapes"))
+		Expect(string(src)).To(ContainSubstring("var _ Timer = (*MockTimer)(nil)"))
+		Expect(check(".")).To(BeEmpty())
+	})
+
+	It("writes doubles into another package", func() {
+		Expect(run(".", []string{"Shape"}, filepath.Join("fakes", "shape.go"), "fakes", "Fake")).To(Succeed())
+		src, err := os.ReadFile(filepath.Join(dir, "fakes", "shape.go"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(src)).To(ContainSubstring(`"example.com/shapes"`))
+		Expect(string(src)).To(ContainSubstring("var _ shapes.Shape = (*FakeShape)(nil)"))
+		Expect(check("./fakes")).To(BeEmpty())
+	})
+
+	It("writes doubles of interfaces from another module", func() {
+		Expect(run("github.com/xeger/gomuti/types", []string{"Clock"}, filepath.Join("fakes", "clock.go"), "fakes", "Mock")).To(Succeed())
+		src, err := os.ReadFile(filepath.Join(dir, "fakes", "clock.go"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(src)).To(ContainSubstring("var _ types.Clock = (*MockClock)(nil)"))
+		Expect(check("./fakes")).To(BeEmpty())
+	})
+
+	It("requires a package name outside the interface's directory", func() {
+		err := run(".", []string{"Shape"}, filepath.Join("fakes", "shape.go"), "", "Mock")
+		Expect(err).To(MatchError(ContainSubstring("must specify -pkg")))
+		Expect(filepath.Join(dir, "fakes", "shape.go")).NotTo(BeAnExistingFile())
+	})
+
+	It("fails for an interface that does not exist", func() {
+		err := run(".", []string{"Circle"}, "mock_shapes.go", "", "Mock")
+		Expect(err).To(MatchError("example.com/shapes.Circle not found"))
+	})
+})
@@ -0,0 +1,220 @@
+package fixture
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"reflect"
+)
+
+// Codec converts the values of one type to and from JSON, so that they can be
+// stored in a fixture. Fixtures identify each value by the name of its type,
+// so a fixture that was saved with a codec can only be loaded with a codec
+// for the same type.
+//
+// A Codec made by Sentinels has no Type; it only lists sentinel errors.
+type Codec struct {
+	Type   reflect.Type
+	Encode func(v interface{}) ([]byte, error)
+	Decode func(data []byte) (interface{}, error)
+
+	// Errors that are replayed as themselves; see Sentinels.
+	Sentinels []error
+}
+
+// JSON returns a Codec that uses encoding/json to convert values of type T,
+// which is suitable for most structs, maps and slices.
+//
+//     fixture.Save(path, double, fixture.JSON[*github.Issue](), fixture.JSON[[]string]())
+func JSON[T any]() Codec {
+	return Codec{
+		Type:   reflect.TypeOf((*T)(nil)).Elem(),
+		Encode: json.Marshal,
+		Decode: func(data []byte) (interface{}, error) {
+			var v T
+			err := json.Unmarshal(data, &v)
+			return v, err
+		},
+	}
+}
+
+// Sentinels returns a Codec that replays the given errors as themselves,
+// rather than as new errors with the same message, so that code under test
+// can recognize them with == or errors.Is:
+//
+//     fixture.Replay(path, double, real, fixture.Sentinels(sql.ErrNoRows))
+//
+// An error is only saved as a sentinel if it is one of them, not if it merely
+// wraps one. Sentinels must have distinct messages, since fixtures identify
+// them by message. The sentinels of packages io, io/fs and context work out of
+// the box.
+func Sentinels(errs ...error) Codec {
+	return Codec{Sentinels: errs}
+}
+
+// The codecs that every fixture uses, in addition to those passed to Save and
+// Load.
+var builtin = []Codec{
+	JSON[bool](),
+	JSON[string](),
+	JSON[int](), JSON[int8](), JSON[int16](), JSON[int32](), JSON[int64](),
+	JSON[uint](), JSON[uint8](), JSON[uint16](), JSON[uint32](), JSON[uint64](),
+	JSON[float32](), JSON[float64](),
+	JSON[[]byte](),
+	Sentinels(io.EOF, io.ErrUnexpectedEOF, io.ErrClosedPipe, io.ErrNoProgress, io.ErrShortBuffer, io.ErrShortWrite,
+		fs.ErrInvalid, fs.ErrPermission, fs.ErrExist, fs.ErrNotExist, fs.ErrClosed,
+		context.Canceled, context.DeadlineExceeded),
+}
+
+// Name under which fixtures record nil values.
+const nilType = "nil"
+
+// Name under which fixtures record errors, which are stored as their messages.
+const errorType = "error"
+
+// Name under which fixtures record sentinel errors, which are stored as their
+// messages and replayed as the sentinel with the same message.
+const sentinelType = "sentinel"
+
+// Name under which fixtures record a []interface{}, such as the variadic params
+// of a generated test double. Each element is stored as a value.
+const listType = "[]interface {}"
+
+// A value in a fixture, tagged with the name of its type.
+type value struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// The codecs that apply to a fixture.
+type codecs struct {
+	byType map[reflect.Type]Codec
+	byName map[string]Codec
+	// sentinel errors by message
+	sentinels map[string]error
+}
+
+func newCodecs(extra []Codec) *codecs {
+	c := &codecs{byType: map[reflect.Type]Codec{}, byName: map[string]Codec{}, sentinels: map[string]error{}}
+	for _, list := range [][]Codec{builtin, extra} {
+		for _, codec := range list {
+			for _, err := range codec.Sentinels {
+				c.sentinels[err.Error()] = err
+			}
+			if codec.Type != nil {
+				c.byType[codec.Type] = codec
+				c.byName[codec.Type.String()] = codec
+			}
+		}
+	}
+	return c
+}
+
+// Reports whether an error is one of the sentinels, as opposed to another
+// error with the same message.
+func (c *codecs) isSentinel(err error) bool {
+	s, ok := c.sentinels[err.Error()]
+	return ok && reflect.TypeOf(s) == reflect.TypeOf(err) && reflect.TypeOf(err).Comparable() && s == err
+}
+
+func (c *codecs) encode(v interface{}) (value, error) {
+	if v == nil {
+		return value{Type: nilType}, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Map, reflect.Ptr, reflect.Slice:
+		// Doubles replay nil as the zero value of the result's type.
+		if rv.IsNil() {
+			return value{Type: nilType}, nil
+		}
+	}
+
+	t := rv.Type()
+	if codec, ok := c.byType[t]; ok {
+		data, err := codec.Encode(v)
+		if err != nil {
+			return value{}, fmt.Errorf("cannot encode %s: %s", t, err)
+		}
+		return value{Type: t.String(), Value: data}, nil
+	}
+
+	switch v := v.(type) {
+	case []interface{}:
+		list, err := c.encodeAll(v)
+		if err != nil {
+			return value{}, err
+		}
+		data, err := json.Marshal(list)
+		return value{Type: listType, Value: data}, err
+	case error:
+		data, err := json.Marshal(v.Error())
+		if c.isSentinel(v) {
+			return value{Type: sentinelType, Value: data}, err
+		}
+		return value{Type: errorType, Value: data}, err
+	}
+	return value{}, fmt.Errorf("no codec for %s; pass one to Save", t)
+}
+
+func (c *codecs) encodeAll(vs []interface{}) ([]value, error) {
+	res := make([]value, len(vs))
+	for i, v := range vs {
+		var err error
+		if res[i], err = c.encode(v); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (c *codecs) decode(v value) (interface{}, error) {
+	switch v.Type {
+	case nilType:
+		return nil, nil
+	case errorType:
+		var msg string
+		err := json.Unmarshal(v.Value, &msg)
+		return errors.New(msg), err
+	case sentinelType:
+		var msg string
+		if err := json.Unmarshal(v.Value, &msg); err != nil {
+			return nil, err
+		}
+		s, ok := c.sentinels[msg]
+		if !ok {
+			return nil, fmt.Errorf("unknown sentinel error %q; pass it to Load with Sentinels", msg)
+		}
+		return s, nil
+	case listType:
+		var list []value
+		if err := json.Unmarshal(v.Value, &list); err != nil {
+			return nil, err
+		}
+		return c.decodeAll(list)
+	}
+
+	codec, ok := c.byName[v.Type]
+	if !ok {
+		return nil, fmt.Errorf("no codec for %s; pass one to Load", v.Type)
+	}
+	res, err := codec.Decode(v.Value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode %s: %s", v.Type, err)
+	}
+	return res, nil
+}
+
+func (c *codecs) decodeAll(vs []value) ([]interface{}, error) {
+	res := make([]interface{}, len(vs))
+	for i, v := range vs {
+		var err error
+		if res[i], err = c.decode(v); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
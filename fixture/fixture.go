@@ -0,0 +1,224 @@
+// Package fixture records the calls that a test double forwards to a real
+// implementation, and replays them later as ordinary allowed calls, so that
+// tests of service clients need not program dozens of calls by hand.
+//
+// The simplest way to use it is Replay. The first time a test runs, Replay
+// makes the real implementation the double's delegate (see
+// types.Mock.SetDelegate) and the test talks to the real service; afterwards,
+// the returned function saves every call that the double's spy observed to a
+// JSON file. On later runs, Replay finds the file and programs the double's
+// mock with each saved call instead:
+//
+//     client := &MockClient{}
+//     save, err := fixture.Replay("testdata/list_users.json", client, realClient)
+//     Expect(err).NotTo(HaveOccurred())
+//     defer func() { Expect(save()).To(Succeed()) }()
+//
+// Each saved call is allowed with literal params, as if by With, and returns
+// its saved results or panics with its saved panic. If the same params were
+// passed several times, the outcomes are replayed in sequence using Then, and
+// the last outcome repeats.
+//
+// Fixtures store each value with the name of its type. Booleans, numbers,
+// strings, []byte, nil and []interface{} (the variadic params of generated
+// doubles) work out of the box; errors are stored as their messages and
+// replayed with errors.New, except for sentinel errors such as io.EOF (see
+// Sentinels), which are replayed as themselves. Other types need a Codec, such
+// as one made by JSON, which must be passed to Save and Load alike.
+//
+// Fixtures are always JSON; other formats, such as YAML, are out of scope.
+//
+// Saving requires a double that records the outcome of its calls with
+// types.Spy.Begin, as generated test doubles do.
+package fixture
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/xeger/gomuti/types"
+)
+
+// Update causes Replay to record new fixtures even if they already exist.
+// Set it (e.g. from a command-line flag) when the real service changes.
+var Update bool
+
+// The contents of a fixture file.
+type fixture struct {
+	Calls []call `json:"calls"`
+}
+
+// A call in a fixture.
+type call struct {
+	Method  string  `json:"method"`
+	Params  []value `json:"params"`
+	Results []value `json:"results,omitempty"`
+	Panic   *value  `json:"panic,omitempty"`
+}
+
+// Replay loads the fixture at path into a test double if the file exists, or
+// else prepares to record one by making real the double's delegate. It returns
+// a function that saves the fixture when recording, and does nothing when
+// replaying; call it after the test has used the double.
+//
+// If the double has a field named Delegate, Replay sets the field; otherwise
+// it calls SetDelegate on the double's Mock.
+func Replay(path string, double interface{}, real interface{}, codecs ...Codec) (func() error, error) {
+	if !Update {
+		_, err := os.Stat(path)
+		if err == nil {
+			return func() error { return nil }, Load(path, double, codecs...)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	v := reflect.ValueOf(double)
+	mock := types.FindMock(v)
+	f := reflect.Indirect(v)
+	if f.Kind() == reflect.Struct {
+		f = f.FieldByName("Delegate")
+	}
+	if f.IsValid() && f.CanSet() && reflect.TypeOf(real).AssignableTo(f.Type()) {
+		f.Set(reflect.ValueOf(real))
+	} else {
+		mock.SetDelegate(real)
+	}
+	return func() error { return Save(path, double, codecs...) }, nil
+}
+
+// Save writes every call that a test double's spy has observed to a fixture
+// file, creating its directory if necessary.
+func Save(path string, double interface{}, codecs ...Codec) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = Write(f, types.FindSpy(reflect.ValueOf(double)), codecs...)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Load reads a fixture file and allows each of its calls on a test double's
+// mock.
+func Load(path string, double interface{}, codecs ...Codec) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := Read(f, types.FindMock(reflect.ValueOf(double)), codecs...); err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	return nil
+}
+
+// Write encodes every call that a spy has observed as a fixture.
+func Write(w io.Writer, spy types.Spy, codecs ...Codec) error {
+	c := newCodecs(codecs)
+	fx := fixture{Calls: []call{}}
+	for _, rc := range spy.AllCalls() {
+		if !rc.Completed {
+			return fmt.Errorf("call %s did not record its outcome; the double must use Spy.Begin", rc)
+		}
+		params, err := c.encodeAll(rc.Params)
+		if err != nil {
+			return fmt.Errorf("%s param: %s", rc.Method, err)
+		}
+		fc := call{Method: rc.Method, Params: params}
+		if rc.Panicked {
+			p, err := c.encode(rc.Panic)
+			if err != nil {
+				return fmt.Errorf("%s panic: %s", rc.Method, err)
+			}
+			fc.Panic = &p
+		} else if fc.Results, err = c.encodeAll(rc.Results); err != nil {
+			return fmt.Errorf("%s result: %s", rc.Method, err)
+		}
+		fx.Calls = append(fx.Calls, fc)
+	}
+
+	data, err := json.MarshalIndent(fx, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Read decodes a fixture and allows each of its calls on a mock. It programs
+// the mock only if the whole fixture could be decoded.
+func Read(r io.Reader, mock types.Mock, codecs ...Codec) error {
+	var fx fixture
+	if err := json.NewDecoder(r).Decode(&fx); err != nil {
+		return err
+	}
+
+	// Group the calls by method and params, in order of first appearance.
+	type outcome struct {
+		results  []interface{}
+		panic    interface{}
+		panicked bool
+	}
+	type group struct {
+		method   string
+		params   []interface{}
+		outcomes []outcome
+	}
+	var groups []*group
+	byKey := map[string]*group{}
+
+	c := newCodecs(codecs)
+	for _, fc := range fx.Calls {
+		params, err := c.decodeAll(fc.Params)
+		if err != nil {
+			return fmt.Errorf("%s param: %s", fc.Method, err)
+		}
+		var o outcome
+		if fc.Panic != nil {
+			if o.panic, err = c.decode(*fc.Panic); err != nil {
+				return fmt.Errorf("%s panic: %s", fc.Method, err)
+			}
+			o.panicked = true
+		} else if o.results, err = c.decodeAll(fc.Results); err != nil {
+			return fmt.Errorf("%s result: %s", fc.Method, err)
+		}
+
+		key, _ := json.Marshal(fc.Params)
+		k := fc.Method + string(key)
+		g := byKey[k]
+		if g == nil {
+			g = &group{method: fc.Method, params: params}
+			byKey[k] = g
+			groups = append(groups, g)
+		}
+		g.outcomes = append(g.outcomes, o)
+	}
+
+	for _, g := range groups {
+		a := mock.Allow().Call(g.method)
+		if len(g.params) > 0 {
+			a.With(g.params...)
+		}
+		for i, o := range g.outcomes {
+			if i > 0 {
+				a = a.Then()
+			}
+			if o.panicked {
+				a.Panic(o.panic)
+			} else {
+				a.Return(o.results...)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package fixture_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/xeger/gomuti"
+	"github.com/xeger/gomuti/fixture"
+	"github.com/xeger/gomuti/types"
+)
+
+type User struct {
+	Name  string
+	Admin bool
+}
+
+type Client interface {
+	Get(name string) (*User, error)
+	Count() int
+	Log(format string, args ...interface{})
+}
+
+// A double written the way gomuti-gen would write it.
+type MockClient struct {
+	Mock     types.Mock
+	Spy      types.Spy
+	Delegate Client
+}
+
+func (m *MockClient) Get(name string) (*User, error) {
+	c := m.Spy.Init().Begin("Get", name)
+	defer c.Recover()
+	r := m.Mock.Call("Get", name)
+	if r == nil && m.Delegate != nil {
+		r = types.CallThrough(m.Delegate, "Get", name)
+	}
+	var r0 *User
+	if len(r) > 0 && r[0] != nil {
+		r0 = r[0].(*User)
+	}
+	var r1 error
+	if len(r) > 1 && r[1] != nil {
+		r1 = r[1].(error)
+	}
+	c.Return(r0, r1)
+	return r0, r1
+}
+
+func (m *MockClient) Count() int {
+	c := m.Spy.Init().Begin("Count")
+	defer c.Recover()
+	r := m.Mock.Call("Count")
+	if r == nil && m.Delegate != nil {
+		r = types.CallThrough(m.Delegate, "Count")
+	}
+	var r0 int
+	if len(r) > 0 && r[0] != nil {
+		r0 = r[0].(int)
+	}
+	c.Return(r0)
+	return r0
+}
+
+func (m *MockClient) Log(format string, args ...interface{}) {
+	c := m.Spy.Init().Begin("Log", format, args)
+	defer c.Recover()
+	r := m.Mock.Call("Log", format, args)
+	if r == nil && m.Delegate != nil {
+		types.CallThrough(m.Delegate, "Log", format, args)
+	}
+	c.Return()
+}
+
+var errGone = errors.New("gone")
+
+// A real Client that counts its calls.
+type realClient struct{ count int }
+
+func (c *realClient) Get(name string) (*User, error) {
+	switch name {
+	case "nobody":
+		return nil, errors.New("not found")
+	case "eof":
+		return nil, io.EOF
+	case "gone":
+		return nil, errGone
+	case "wrapped":
+		return nil, fmt.Errorf("wrapped: %w", errGone)
+	}
+	return &User{Name: name, Admin: name == "root"}, nil
+}
+
+func (c *realClient) Count() int {
+	c.count++
+	return c.count
+}
+
+func (c *realClient) Log(format string, args ...interface{}) {
+	if format == "" {
+		panic("empty format")
+	}
+}
+
+var _ = Describe("fixture", func() {
+	var dir, path string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "fixture")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(dir, "testdata", "client.json")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	exercise := func(c Client) {
+		Expect(c.Get("alice")).To(Equal(&User{Name: "alice"}))
+		Expect(c.Get("root")).To(Equal(&User{Name: "root", Admin: true}))
+		_, err := c.Get("nobody")
+		Expect(err).To(MatchError("not found"))
+		Expect(c.Count()).To(Equal(1))
+		Expect(c.Count()).To(Equal(2))
+		Expect(c.Count()).To(Equal(3))
+		c.Log("hello %s %d", "world", 42)
+		Expect(func() { c.Log("") }).To(Panic())
+	}
+
+	It("records calls to a real implementation and replays them", func() {
+		real := &realClient{}
+		double := &MockClient{}
+		save, err := fixture.Replay(path, double, real, fixture.JSON[*User]())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(double.Delegate).To(BeIdenticalTo(real))
+		exercise(double)
+		Expect(save()).To(Succeed())
+		Expect(path).To(BeAnExistingFile())
+
+		replayed := &MockClient{}
+		save, err = fixture.Replay(path, replayed, &realClient{}, fixture.JSON[*User]())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(replayed.Delegate).To(BeNil())
+		exercise(replayed)
+		Expect(replayed.Count()).To(Equal(3))
+		Expect(save()).To(Succeed())
+		Expect(replayed).To(HaveCall("Log").With("hello %s %d", VariadicWith("world", 42)))
+	})
+
+	It("re-records when Update is set", func() {
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(`{"calls": []}`), 0644)).To(Succeed())
+
+		fixture.Update = true
+		defer func() { fixture.Update = false }()
+		double := &MockClient{}
+		save, err := fixture.Replay(path, double, &realClient{}, fixture.JSON[*User]())
+		Expect(err).NotTo(HaveOccurred())
+		double.Count()
+		Expect(save()).To(Succeed())
+
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring(`"method": "Count"`))
+	})
+
+	It("requires codecs for unfamiliar types", func() {
+		double := &MockClient{Delegate: &realClient{}}
+		double.Get("alice")
+		Expect(fixture.Save(path, double)).To(MatchError(ContainSubstring("no codec for *fixture_test.User")))
+
+		double.Spy.Reset()
+		double.Get("nobody")
+		Expect(fixture.Save(path, double)).To(Succeed())
+		Expect(fixture.Load(path, &MockClient{})).To(Succeed())
+		Expect(fixture.Load(path, &types.Mock{})).To(Succeed())
+
+		Expect(os.WriteFile(path, []byte(`{"calls": [{"method": "At", "params": [{"type": "time.Time", "value": "2020-01-01T00:00:00Z"}]}]}`), 0644)).To(Succeed())
+		Expect(fixture.Load(path, &MockClient{})).To(MatchError(ContainSubstring("no codec for time.Time")))
+
+		m := types.Mock{}
+		Expect(fixture.Load(path, m, fixture.JSON[time.Time]())).To(Succeed())
+		Expect(m.Call("At", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))).NotTo(BeNil())
+	})
+
+	It("replays sentinel errors as themselves", func() {
+		double := &MockClient{Delegate: &realClient{}}
+		for _, name := range []string{"eof", "gone", "wrapped"} {
+			double.Get(name)
+		}
+		Expect(fixture.Save(path, double, fixture.Sentinels(errGone))).To(Succeed())
+
+		replayed := &MockClient{}
+		Expect(fixture.Load(path, replayed, fixture.Sentinels(errGone))).To(Succeed())
+		_, err := replayed.Get("eof")
+		Expect(err).To(BeIdenticalTo(io.EOF))
+		_, err = replayed.Get("gone")
+		Expect(err).To(BeIdenticalTo(errGone))
+		_, err = replayed.Get("wrapped")
+		Expect(err).To(MatchError("wrapped: gone"))
+		Expect(errors.Is(err, errGone)).To(BeFalse())
+
+		Expect(fixture.Load(path, &MockClient{})).To(MatchError(ContainSubstring(`unknown sentinel error "gone"`)))
+	})
+
+	It("refuses to save calls whose outcome is unknown", func() {
+		s := types.Spy{}
+		s.Observe("Count")
+		Expect(fixture.Write(&bytes.Buffer{}, s)).To(MatchError(ContainSubstring("did not record its outcome")))
+	})
+})
@@ -9,6 +9,10 @@
 // 3) Anything, AnythingOfType: parameter matchers used with mocking and spying methods.
 // Gomega matchers can be used as Gomuti parameter matchers: BeNumerically, HaveOccurred, etc.
 //
+// Allowed calls can also be marked as Required, turning the test double into a
+// strict mock; Verify reports any required call that was not matched as often
+// as expected.
+//
 // All of these methods rely on the Mock and Spy types exported by package
 // gomuti/types; test doubles are generally struct types that contain exported
 // fields of type Mock and Spy. The DSL operates on pointers to these structs
@@ -18,16 +22,19 @@
 // benefit of passing pointers is that the nested Mock or Spy will be allocated
 // as needed with no intervention by the caller.
 //
-// Stubbing is provided by the mongoose package (https://github.com/xeger/mongoose),
-// which also generates Gomuti-compatible mock code for any interface. Stubbed methods
+// Stubbing is provided by generated code: the gomuti-gen command (in cmd/gomuti-gen)
+// and the mongoose package (https://github.com/xeger/mongoose) both generate
+// Gomuti-compatible mock code for any interface. Stubbed methods
 // are called whenever no mock expectations match a method call; the return value(s)
 // from a stubbed method call are always zero values. Stubbing must be enabled on
 // a per-object basis by setting the Stub field to true.
 package gomuti
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/xeger/gomuti/types"
 )
@@ -37,9 +44,22 @@ import (
 // its methods are called.
 //
 // For information about parameter matching and return values, see types.Allowed.
+// If the double has a method with the name that you pass to Call, then Do and
+// Return check that their functions and values fit that method's signature.
 func Allow(double interface{}) *types.Allowed {
 	m := types.FindMock(reflect.ValueOf(double))
-	return m.Allow()
+	return m.AllowFor(double)
+}
+
+// AllowFunc is a type-safe mocking method. It is like Allow(double).Call(method),
+// but F is the signature of the method; Do accepts only functions of type F,
+// and With and Return panic right away if their values don't fit F. See
+// types.AllowedFunc for details.
+//
+// Example:
+//     AllowFunc[func(int64, int64) int64](adder, "Add").With(int64(2), Anything()).Return(int64(4))
+func AllowFunc[F any](double interface{}, method string) *types.AllowedFunc[F] {
+	return types.AllowFunc[F](types.FindMock(reflect.ValueOf(double)), method)
 }
 
 // Â is a mocking method that is an alias for Allow. Use Shift+Option+M to
@@ -60,12 +80,138 @@ func Â(double interface{}, methodAndParams ...interface{}) *types.Allowed {
 
 	m, ok := methodAndParams[0].(string)
 	if !ok {
-		panic(fmt.Sprintf("gomuti.Â: expected string as method name; got %T", methodAndParams[0]))
+		panic(&types.DSLMisuseError{Method: "Â", Reason: fmt.Sprintf("expected string as method name; got %T", methodAndParams[0])})
 	}
 
 	p := methodAndParams[1:]
 	if len(p) > 0 {
-		return Allow(double).Call(m).With(p)
+		return Allow(double).Call(m).With(p...)
 	}
 	return Allow(double).Call(m)
 }
+
+// New builds a test double at run time for code that depends upon a function
+// type, or upon a struct whose fields are functions. The Impl of the returned
+// double is the function (or struct); calls to it are recorded by the double's
+// Spy and answered by its Mock, under the name of the field or, for a function
+// type, under the name "Func". New cannot implement interfaces, because Go
+// cannot create methods at run time; generate their doubles with gomuti-gen.
+//
+// Example:
+//     double := New[func(string) (int, error)]()
+//     Allow(double).Call("Func").With("42").Return(42, nil)
+//     subject := NewParser(double.Impl)
+//     Expect(double).To(HaveCall("Func").Once())
+func New[T any]() *types.Double[T] {
+	return types.NewDouble[T]()
+}
+
+// Double is like New, but takes the type of the function or struct as a
+// reflect.Type, for use when the type is not known at compile time. The
+// returned double's Impl holds a value of that type.
+func Double(t reflect.Type) *types.Double[interface{}] {
+	return types.NewDoubleOf(t)
+}
+
+// Strict is a mocking method that turns on strict matching for a test double:
+// whenever several allowed calls match a method call equally well, the double
+// panics with a types.AmbiguousMatchError instead of choosing the most recently
+// allowed call.
+func Strict(double interface{}) {
+	types.FindMock(reflect.ValueOf(double)).SetStrict(true)
+}
+
+// Verify is a mocking method. It checks that every required call programmed
+// into a test double (see types.Allowed.Required and types.Allowed.Times) was
+// matched the expected number of times, and returns an error describing each
+// call that was under- or over-satisfied. It returns nil if all required calls
+// are satisfied.
+//
+// Example:
+//     Allow(double).Call("Open").With("/etc/hosts").Required().Return(f, nil)
+//     Allow(double).Call("Close").Once().Return(nil)
+//     subject.DoStuff()
+//     Expect(Verify(double)).To(Succeed())
+func Verify(double interface{}) error {
+	m := HaveMetExpectations()
+	if ok, _ := m.Match(double); ok {
+		return nil
+	}
+	return errors.New(m.FailureMessage(double))
+}
+
+// VerifyAll calls Verify for each of several test doubles and combines any
+// errors into one.
+func VerifyAll(doubles ...interface{}) error {
+	var msgs []string
+	for i, d := range doubles {
+		if err := Verify(d); err != nil {
+			msgs = append(msgs, fmt.Sprintf("%d: %T: %s", i, d, err.Error()))
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "\n"))
+	}
+	return nil
+}
+
+// Reset forgets the behaviors programmed into a test double and the calls
+// that it has observed, so that a double shared by several tests doesn't leak
+// state between them. The double may contain a Mock, a Spy or both.
+func Reset(double interface{}) {
+	merr := tryReset(func() { ResetBehaviors(double) })
+	serr := tryReset(func() { ResetObservations(double) })
+	if merr != nil && serr != nil {
+		panic(merr)
+	}
+}
+
+// ResetBehaviors forgets the behaviors programmed into a test double's Mock
+// (see types.Mock.Reset) but keeps the calls observed by its Spy.
+func ResetBehaviors(double interface{}) {
+	types.FindMock(reflect.ValueOf(double)).Reset()
+}
+
+// ResetObservations forgets the calls observed by a test double's Spy (see
+// types.Spy.Reset) but keeps the behaviors programmed into its Mock.
+func ResetObservations(double interface{}) {
+	types.FindSpy(reflect.ValueOf(double)).Reset()
+}
+
+// Release unblocks every call to a test double that is hanging (see
+// types.Allowed.Hang). Reset releases hanging calls too.
+func Release(double interface{}) {
+	types.FindMock(reflect.ValueOf(double)).Release()
+}
+
+// Resetter returns a function that resets several test doubles. Pass it to
+// Ginkgo's AfterEach:
+//
+//     AfterEach(Resetter(adder, logger))
+//
+// To verify the doubles before resetting them, use
+// gomutigomega.VerifyingResetter instead.
+func Resetter(doubles ...interface{}) func() {
+	return func() {
+		for _, d := range doubles {
+			Reset(d)
+		}
+	}
+}
+
+// Calls fn, which resets a Mock or Spy, and returns the DSLMisuseError that
+// it panics with if the double doesn't have one. A nil Mock or Spy in a double
+// that was passed by value has nothing to reset, so it is not an error.
+func tryReset(fn func()) (err *types.DSLMisuseError) {
+	defer func() {
+		switch r := recover().(type) {
+		case nil, *types.UninitializedError:
+		case *types.DSLMisuseError:
+			err = r
+		default:
+			panic(r)
+		}
+	}()
+	fn()
+	return nil
+}
@@ -15,6 +15,11 @@ type bad struct {
 	mock types.Mock
 }
 
+type spied struct {
+	Mock types.Mock
+	Spy  types.Spy
+}
+
 var _ = Describe("Allow", func() {
 	It("has an RSpec-like DSL", func() {
 		double := good{Mock: types.Mock{}}
@@ -27,6 +32,12 @@ var _ = Describe("Allow", func() {
 		Â(double, "Foo", 2)
 	})
 
+	It("matches shortcut params one by one", func() {
+		double := types.Mock{}
+		Â(double, "Foo", 2, 1).Return(3)
+		Expect(double.Call("Foo", 2, 1)).To(Equal([]interface{}{3}))
+	})
+
 	It("accepts Mock", func() {
 		double := types.Mock{}
 		Â(double, "Foo", 1)
@@ -60,4 +71,114 @@ var _ = Describe("Allow", func() {
 			Â(double, "Foo", 1)
 		}).To(Panic())
 	})
+
+	It("panics with UninitializedError", func() {
+		Expect(func() {
+			double := good{}
+			Â(double, "Foo", 1)
+		}).To(PanicWith(&types.UninitializedError{Type: "gomuti_test.good", Field: "Mock"}))
+
+		Expect(func() {
+			var double types.Mock
+			Â(double, "Foo", 1)
+		}).To(PanicWith(&types.UninitializedError{Type: "types.Mock", Method: "Allow"}))
+	})
+})
+
+var _ = Describe("Verify", func() {
+	var double *good
+
+	BeforeEach(func() {
+		double = &good{}
+	})
+
+	It("succeeds when nothing is required", func() {
+		Allow(double).Call("Foo").Return(1)
+		Expect(Verify(double)).To(Succeed())
+	})
+
+	It("reports required calls that were never matched", func() {
+		Allow(double).Call("Foo").With(42).Required().Return(1)
+		err := Verify(double)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Foo: expected at least 1 call but matched 0 calls with:"))
+		Expect(err.Error()).To(ContainSubstring("0: Equal(42)"))
+
+		double.Mock.Call("Foo", 42)
+		double.Mock.Call("Foo", 42)
+		Expect(Verify(double)).To(Succeed())
+	})
+
+	It("reports calls that were matched too often", func() {
+		Allow(double).Call("Foo").Once().Return(1)
+		Allow(double).Call("Bar").Never()
+		double.Mock.Call("Foo")
+		Expect(Verify(double)).To(Succeed())
+
+		double.Mock.Call("Foo")
+		double.Mock.Call("Bar")
+		err := Verify(double)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Bar: expected 0 calls but matched 1 call with any parameters"))
+		Expect(err.Error()).To(ContainSubstring("Foo: expected 1 call but matched 2 calls with any parameters"))
+	})
+
+	It("verifies several doubles at once", func() {
+		other := &good{}
+		Allow(double).Call("Foo").Required()
+		Allow(other).Call("Bar").Required()
+		double.Mock.Call("Foo")
+		Expect(VerifyAll(double, other)).To(MatchError(ContainSubstring("1: *gomuti_test.good")))
+		other.Mock.Call("Bar")
+		Expect(VerifyAll(double, other)).To(Succeed())
+	})
+
+	It("works as a matcher", func() {
+		Allow(double).Call("Foo").Twice()
+		double.Mock.Call("Foo")
+		Expect(double).NotTo(HaveMetExpectations())
+		double.Mock.Call("Foo")
+		Expect(double).To(HaveMetExpectations())
+	})
+})
+
+var _ = Describe("Reset", func() {
+	var double *spied
+
+	BeforeEach(func() {
+		double = &spied{}
+		Allow(double).Call("Foo").Return(1)
+		double.Spy.Init().Observe("Foo")
+	})
+
+	It("forgets behaviors and observations", func() {
+		Reset(double)
+		Expect(double.Mock.Call("Foo")).To(BeNil())
+		Expect(double).To(HaveCall("Foo").Never())
+	})
+
+	It("resets only behaviors or only observations", func() {
+		ResetBehaviors(double)
+		Expect(double.Mock.Call("Foo")).To(BeNil())
+		Expect(double).To(HaveCall("Foo").Once())
+
+		Allow(double).Call("Foo").Return(1)
+		ResetObservations(double)
+		Expect(double.Mock.Call("Foo")).NotTo(BeNil())
+		Expect(double).To(HaveCall("Foo").Never())
+	})
+
+	It("accepts doubles with only a Mock or a Spy", func() {
+		Reset(&good{})
+		Reset(types.Spy{})
+		Expect(func() { Reset(42) }).To(PanicWith(BeAssignableToTypeOf(&types.DSLMisuseError{})))
+	})
+
+	It("keeps settings of the Mock", func() {
+		Strict(double)
+		Reset(double)
+		Allow(double).Call("Foo").Return(1)
+		Allow(double).Call("Foo").Return(2)
+		Expect(func() { double.Mock.Call("Foo") }).To(PanicWith(BeAssignableToTypeOf(&types.AmbiguousMatchError{})))
+	})
 })
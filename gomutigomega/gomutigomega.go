@@ -0,0 +1,21 @@
+// Package gomutigomega holds the parts of Gomuti that report failures through
+// Gomega. Package gomuti itself does not depend on Gomega, so that plain Go
+// tests (see package gomutitest) can use it without pulling Gomega in.
+package gomutigomega
+
+import (
+	"github.com/onsi/gomega"
+	"github.com/xeger/gomuti"
+)
+
+// VerifyingResetter returns a function that verifies several test doubles
+// (see gomuti.VerifyAll), failing the current test with Gomega if they have
+// unmet expectations, and then resets them. Pass it to Ginkgo's AfterEach:
+//
+//     AfterEach(gomutigomega.VerifyingResetter(adder, logger))
+func VerifyingResetter(doubles ...interface{}) func() {
+	return func() {
+		defer gomuti.Resetter(doubles...)()
+		gomega.ExpectWithOffset(1, gomuti.VerifyAll(doubles...)).To(gomega.Succeed())
+	}
+}
@@ -0,0 +1,13 @@
+package gomutigomega_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestGomutigomega(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Gomutigomega Suite")
+}
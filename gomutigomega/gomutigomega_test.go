@@ -0,0 +1,28 @@
+package gomutigomega_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/xeger/gomuti"
+	"github.com/xeger/gomuti/gomutigomega"
+	"github.com/xeger/gomuti/types"
+)
+
+type spied struct {
+	Mock types.Mock
+	Spy  types.Spy
+}
+
+var _ = Describe("VerifyingResetter", func() {
+	It("verifies before resetting", func() {
+		double := &spied{}
+		Allow(double).Call("Bar").Required()
+		failures := InterceptGomegaFailures(gomutigomega.VerifyingResetter(double))
+		Expect(failures).To(HaveLen(1))
+		Expect(failures[0]).To(ContainSubstring("Bar: expected at least 1 call"))
+		Expect(double.Mock.Unsatisfied()).To(BeEmpty())
+
+		Resetter(double)()
+		Expect(InterceptGomegaFailures(gomutigomega.VerifyingResetter(double))).To(BeEmpty())
+	})
+})
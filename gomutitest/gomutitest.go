@@ -0,0 +1,105 @@
+// Package gomutitest lets you verify Gomuti test doubles from plain Go tests,
+// without Ginkgo or Gomega's Expect. Each assertion reports a failure with
+// t.Errorf, using the same messages as the equivalent Gomega matcher, and
+// returns whether it passed:
+//
+//     func TestMultiply(t *testing.T) {
+//       adder := &MockAdder{}
+//       gomutitest.VerifyOnCleanup(t, adder)
+//       gomuti.Allow(adder).Call("Add").Times(2).Return(int64(4))
+//
+//       subject := Calculator{adder}
+//       subject.Multiply(2, 3)
+//
+//       gomutitest.AssertCalled(t, adder, "Add", int64(2), int64(2))
+//       gomutitest.AssertNotCalled(t, adder, "Subtract")
+//     }
+//
+// As with gomuti.Allow and HaveCall, each param may be a literal value or a
+// matcher.
+package gomutitest
+
+import (
+	"testing"
+
+	"github.com/xeger/gomuti"
+	"github.com/xeger/gomuti/matchers"
+	"github.com/xeger/gomuti/types"
+)
+
+// AssertCalled asserts that a test double received at least one call to a
+// method with matching params. If no params are specified, calls with any
+// params are counted.
+func AssertCalled(t testing.TB, double interface{}, method string, params ...interface{}) bool {
+	t.Helper()
+	return assertCall(t, double, call(method, params).AtLeast(1))
+}
+
+// AssertNotCalled asserts that a test double received no call to a method
+// with matching params.
+func AssertNotCalled(t testing.TB, double interface{}, method string, params ...interface{}) bool {
+	t.Helper()
+	return assertCall(t, double, call(method, params).Never())
+}
+
+// AssertCallCount asserts that a test double received exactly count calls to
+// a method with matching params.
+func AssertCallCount(t testing.TB, double interface{}, count int, method string, params ...interface{}) bool {
+	t.Helper()
+	return assertCall(t, double, call(method, params).Times(count))
+}
+
+// AssertExpectations asserts that every required call programmed into a test
+// double (see types.Allowed.Required and types.Allowed.Times) was matched the
+// expected number of times.
+func AssertExpectations(t testing.TB, double interface{}) bool {
+	t.Helper()
+	if err := gomuti.Verify(double); err != nil {
+		t.Errorf("%s", err)
+		return false
+	}
+	return true
+}
+
+// VerifyOnCleanup calls AssertExpectations for each of several test doubles
+// when the test (or subtest) finishes, then resets them (see gomuti.Reset).
+func VerifyOnCleanup(t testing.TB, doubles ...interface{}) {
+	t.Helper()
+	t.Cleanup(func() {
+		t.Helper()
+		defer gomuti.Resetter(doubles...)()
+		for _, d := range doubles {
+			AssertExpectations(t, d)
+		}
+	})
+}
+
+// ResetOnCleanup resets several test doubles (see gomuti.Reset) when the test
+// (or subtest) finishes, so that doubles shared by several tests don't leak
+// state between them.
+func ResetOnCleanup(t testing.TB, doubles ...interface{}) {
+	t.Helper()
+	t.Cleanup(gomuti.Resetter(doubles...))
+}
+
+// Returns a matcher for calls to method with the given params.
+func call(method string, params []interface{}) *matchers.HaveCallMatcher {
+	m := gomuti.HaveCall(method)
+	if len(params) > 0 {
+		m.Params = types.MatchParams(params)
+	}
+	return m
+}
+
+// Reports a failure unless the double satisfies the matcher.
+func assertCall(t testing.TB, double interface{}, m *matchers.HaveCallMatcher) bool {
+	t.Helper()
+	ok, err := m.Match(double)
+	if err != nil {
+		t.Errorf("%s", err)
+		return false
+	} else if !ok {
+		t.Errorf("%s", m.FailureMessage(double))
+	}
+	return ok
+}
@@ -0,0 +1,127 @@
+package gomutitest_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/xeger/gomuti"
+	"github.com/xeger/gomuti/gomutitest"
+	"github.com/xeger/gomuti/types"
+)
+
+// A testing.TB that records failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Cleanup(fn func()) {
+	r.cleanups = append(r.cleanups, fn)
+}
+
+// A hand-written test double.
+type adder struct {
+	Mock types.Mock
+	Spy  types.Spy
+}
+
+func (a *adder) Add(l, r int) int {
+	a.Spy.Init().Observe("Add", l, r)
+	a.Mock.Call("Add", l, r)
+	return l + r
+}
+
+func TestAssertCalled(t *testing.T) {
+	r := &recorder{TB: t}
+	double := &adder{}
+	double.Add(1, 2)
+
+	if !gomutitest.AssertCalled(r, double, "Add") || !gomutitest.AssertCalled(r, double, "Add", 1, 2) {
+		t.Errorf("expected call to be found; got %v", r.errors)
+	}
+	if gomutitest.AssertCalled(r, double, "Add", 3, gomuti.Anything()) {
+		t.Error("expected call not to be found")
+	}
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "Closest match") {
+		t.Errorf("expected one failure with closest match; got %v", r.errors)
+	}
+}
+
+func TestAssertNotCalled(t *testing.T) {
+	r := &recorder{TB: t}
+	double := &adder{}
+	double.Add(1, 2)
+
+	if !gomutitest.AssertNotCalled(r, double, "Add", 2, 2) || !gomutitest.AssertNotCalled(r, double, "Subtract") {
+		t.Errorf("expected calls not to be found; got %v", r.errors)
+	}
+	if gomutitest.AssertNotCalled(r, double, "Add") {
+		t.Error("expected call to be found")
+	}
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "more than the maximum of 0") {
+		t.Errorf("expected one failure; got %v", r.errors)
+	}
+}
+
+func TestAssertCallCount(t *testing.T) {
+	r := &recorder{TB: t}
+	double := &adder{}
+	double.Add(1, 2)
+	double.Add(1, 3)
+
+	if !gomutitest.AssertCallCount(r, double, 2, "Add") || !gomutitest.AssertCallCount(r, double, 1, "Add", 1, 3) {
+		t.Errorf("expected counts to match; got %v", r.errors)
+	}
+	if gomutitest.AssertCallCount(r, double, 3, "Add") {
+		t.Error("expected count not to match")
+	}
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "Expected 3 calls to Add") {
+		t.Errorf("expected one failure; got %v", r.errors)
+	}
+}
+
+func TestVerifyOnCleanup(t *testing.T) {
+	r := &recorder{TB: t}
+	double := &adder{}
+	gomutitest.VerifyOnCleanup(r, double)
+	gomuti.Allow(double).Call("Add").With(1, 2).Once()
+	gomuti.Allow(double).Call("Add").With(2, 2).Required()
+	double.Add(1, 2)
+
+	if len(r.cleanups) != 1 {
+		t.Fatalf("expected a cleanup to be registered; got %d", len(r.cleanups))
+	}
+	r.cleanups[0]()
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "Add: expected at least 1 call but matched 0 calls") {
+		t.Errorf("expected one failure; got %v", r.errors)
+	}
+
+	if double.Mock.Call("Add", 1, 2) != nil || len(double.Spy.AllCalls()) != 0 {
+		t.Error("expected the double to be reset")
+	}
+
+	if !gomutitest.AssertExpectations(r, &adder{}) {
+		t.Error("expected a double without requirements to pass")
+	}
+}
+
+func TestResetOnCleanup(t *testing.T) {
+	r := &recorder{TB: t}
+	double := &adder{}
+	gomutitest.ResetOnCleanup(r, double)
+	gomuti.Allow(double).Call("Add").Return(3)
+	double.Add(1, 2)
+
+	r.cleanups[0]()
+	if double.Mock.Call("Add", 1, 2) != nil || len(double.Spy.AllCalls()) != 0 {
+		t.Error("expected the double to be reset")
+	}
+}
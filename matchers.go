@@ -1,8 +1,8 @@
 package gomuti
 
 import (
-	"github.com/onsi/gomega/types"
 	"github.com/xeger/gomuti/matchers"
+	"github.com/xeger/gomuti/types"
 )
 
 // BeAnything creates a matcher that is always satisfied. It is useful when
@@ -12,7 +12,7 @@ import (
 // Example:
 //
 //     Allow(boat).Call("Sail").With("west", BeAnything(), "mi").Panic("Please use kilometers")
-func BeAnything() types.GomegaMatcher {
+func BeAnything() *matchers.BeAnythingMatcher {
 	return &matchers.BeAnythingMatcher{}
 }
 
@@ -24,10 +24,38 @@ func BeAnything() types.GomegaMatcher {
 //     Allow(boat).Call("Sail").With("west", Anything(), "mi").Panic("Please use kilometers")
 //
 // Which makes more sense than "with be-anything."
-func Anything() types.GomegaMatcher {
+func Anything() *matchers.BeAnythingMatcher {
 	return BeAnything()
 }
 
+// Rest creates a matcher for all of the remaining parameters of a method call,
+// typically the values passed to a variadic parameter. It must be the final
+// parameter given to With. The remaining parameters are passed to matcher as
+// a []interface{}; if matcher is not a matcher, they must be equivalent to it.
+//
+// Example:
+//
+//     Allow(logger).Call("Log").With("info", Anything(), Rest(ContainElement("user")))
+//     Expect(logger).To(HaveCall("Log").With("debug", Rest(BeEmpty())))
+func Rest(matcher interface{}) *types.RestMatcher {
+	return &types.RestMatcher{Matcher: types.MatchParams([]interface{}{matcher})[0]}
+}
+
+// VariadicWith creates a matcher for all of the remaining parameters of a
+// method call, typically the values passed to a variadic parameter. It must be
+// the final parameter given to With. Each remaining parameter is matched
+// against the corresponding value or matcher, in the same way as the
+// parameters given to With; there must be exactly as many parameters as
+// values.
+//
+// Example:
+//
+//     Allow(logger).Call("Log").With("info", "%s logged in", VariadicWith("alice"))
+//     Expect(logger).To(HaveCall("Log").With("info", Anything(), VariadicWith(Anything(), 42)))
+func VariadicWith(params ...interface{}) *types.RestMatcher {
+	return &types.RestMatcher{Each: types.MatchParams(params)}
+}
+
 // HaveType creates a matcher that is satisfied by any value whose type matches
 // the specified name. Example:
 //
@@ -40,7 +68,7 @@ func Anything() types.GomegaMatcher {
 //
 //     import banana "time"
 //		 Expect(banana.Now()).To(HaveType("time.Time"))
-func HaveType(name string) types.GomegaMatcher {
+func HaveType(name string) *matchers.HaveTypeMatcher {
 	return &matchers.HaveTypeMatcher{Expected: name}
 }
 
@@ -52,7 +80,7 @@ func HaveType(name string) types.GomegaMatcher {
 //     Allow(myMock).ToReceive("Foo").With(AnythingOfType("mypkg.Widget"))
 //
 // Which makes more sense than "with have-type."
-func AnythingOfType(name string) types.GomegaMatcher {
+func AnythingOfType(name string) *matchers.HaveTypeMatcher {
 	return HaveType(name)
 }
 
@@ -60,8 +88,13 @@ func AnythingOfType(name string) types.GomegaMatcher {
 // was recorded by a spy. You can add more verifications (of parameter values,
 // call count, etc) by calling methods on the returned matcher.
 //
+// By default the matcher is satisfied by one or more matching calls; Times,
+// Once, Twice and Never demand an exact count, while AtLeast, AtMost and
+// Between impose bounds.
+//
 // Example:
 //     Expect(double).To(HaveCall("Bar").With(true, 42).Twice())
+//     Expect(double).To(HaveCall("Baz").AtMost(3))
 func HaveCall(method string) *matchers.HaveCallMatcher {
 	return &matchers.HaveCallMatcher{Method: method, Count: 1}
 }
@@ -70,3 +103,30 @@ func HaveCall(method string) *matchers.HaveCallMatcher {
 func HaveReceived(method string) *matchers.HaveCallMatcher {
 	return HaveCall(method)
 }
+
+// HaveCallsInOrder is a spy method. It returns a matcher to verify that
+// several method calls happened in the specified order. Each step is a
+// HaveCall matcher; by default, steps consult the spy of the actual value,
+// but you can call On() to verify ordering across several test doubles.
+//
+// Example:
+//     Expect(file).To(HaveCallsInOrder(
+//       HaveCall("Open"),
+//       HaveCall("Write").With(Anything()).Twice(),
+//       HaveCall("Info").On(logger),
+//       HaveCall("Close"),
+//     ))
+func HaveCallsInOrder(steps ...*matchers.HaveCallMatcher) *matchers.HaveCallsInOrderMatcher {
+	return &matchers.HaveCallsInOrderMatcher{Steps: steps}
+}
+
+// HaveMetExpectations is a mock method. It returns a matcher to verify that
+// every required call programmed into a test double was matched the expected
+// number of times.
+//
+// Example:
+//     Allow(double).Call("Close").Once().Return(nil)
+//     Expect(double).To(HaveMetExpectations())
+func HaveMetExpectations() *matchers.HaveMetExpectationsMatcher {
+	return &matchers.HaveMetExpectationsMatcher{}
+}
@@ -1,6 +1,6 @@
 package matchers
 
-import "github.com/onsi/gomega/format"
+import "github.com/xeger/gomuti/types"
 
 // BeAnythingMatcher matches _any_ value including nil and zero values.
 type BeAnythingMatcher struct{}
@@ -12,10 +12,10 @@ func (m *BeAnythingMatcher) Match(actual interface{}) (bool, error) {
 
 // FailureMessage returns a description of why the matcher did not match.
 func (m *BeAnythingMatcher) FailureMessage(actual interface{}) string {
-	return format.Message(actual, "to be any value")
+	return types.FormatMessage(actual, "to be any value")
 }
 
 // NegatedFailureMessage returns a description of why the matcher matched.
 func (m *BeAnythingMatcher) NegatedFailureMessage(actual interface{}) string {
-	return format.Message(actual, "not to be a value")
+	return types.FormatMessage(actual, "not to be a value")
 }
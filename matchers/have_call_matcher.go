@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/xeger/gomuti/types"
 )
@@ -71,32 +72,97 @@ func formatParamInfo(b *bytes.Buffer, indent int, params []interface{}) string {
 
 // HaveCallMatcher consults the spy of a test double in order to verify that
 // method calls were received with specified parameters.
+//
+// Count is the minimum number of matching calls. By default there is no
+// maximum; use Times, AtMost or Between to impose one.
+//
+// If Double is non-nil, the matcher consults its spy instead of the actual
+// value being matched; this lets you verify the relative order of calls to
+// several test doubles with HaveCallsInOrderMatcher.
+//
+// Results, Panics, PanicReason and Within constrain the outcome of matching
+// calls. They only work with test doubles that record outcomes with
+// types.Spy.Begin, as generated doubles do.
 type HaveCallMatcher struct {
 	Method string
 	Params []types.Matcher
 	Count  int
+	Double interface{}
+
+	Results     []types.Matcher
+	Panics      bool
+	PanicReason types.Matcher
+	Within      time.Duration
+
+	max     int
+	bounded bool
+}
+
+// Returns the spy that this matcher should consult.
+func (sm *HaveCallMatcher) spy(actual interface{}) types.Spy {
+	if sm.Double != nil {
+		actual = sm.Double
+	}
+	return types.FindSpy(reflect.ValueOf(actual))
+}
+
+// Returns the spy that this matcher should consult, keeping only the calls
+// whose outcome satisfies the matcher.
+func (sm *HaveCallMatcher) observed(actual interface{}) types.Spy {
+	spy := sm.spy(actual)
+	if spy == nil || (sm.Results == nil && !sm.Panics && sm.Within <= 0) {
+		return spy
+	}
+	return spy.Filter(sm.outcome)
+}
+
+// Determine whether the outcome of a call satisfies the matcher.
+func (sm *HaveCallMatcher) outcome(rc types.RecordedCall) bool {
+	if !rc.Completed {
+		return false
+	} else if sm.Within > 0 && rc.Duration >= sm.Within {
+		return false
+	} else if sm.Panics {
+		if !rc.Panicked {
+			return false
+		} else if sm.PanicReason != nil {
+			ok, _ := sm.PanicReason.Match(rc.Panic)
+			return ok
+		}
+	} else if sm.Results != nil {
+		if rc.Panicked || len(rc.Results) != len(sm.Results) {
+			return false
+		}
+		for i, r := range sm.Results {
+			if ok, _ := r.Match(rc.Results[i]); !ok {
+				return false
+			}
+		}
+	}
+	return true
 }
 
 // Match verifies that a method was called on a mock
 func (sm *HaveCallMatcher) Match(actual interface{}) (bool, error) {
-	spy := types.FindSpy(reflect.ValueOf(actual))
+	spy := sm.observed(actual)
 	if spy == nil {
 		return false, fmt.Errorf("Cannot spy on %T", actual)
 	}
 	matched := spy.Count(sm.Method, sm.Params...)
-	return matched >= sm.Count, nil
+	return sm.satisfied(matched), nil
 }
 
 // FailureMessage returns an explanation of the method call that was expected.
 func (sm *HaveCallMatcher) FailureMessage(actual interface{}) (message string) {
-	spy := types.FindSpy(reflect.ValueOf(actual))
+	spy := sm.spy(actual)
 	if spy == nil {
 		return fmt.Sprintf("Cannot spy on %T", actual)
 	}
-	matched := spy.Count(sm.Method, sm.Params...)
+	matched := sm.observed(actual).Count(sm.Method, sm.Params...)
 
+	// Suggest the closest match only if no call had the expected params.
 	var closest []interface{}
-	if spy.Count(sm.Method) > 0 {
+	if spy.Count(sm.Method) > 0 && spy.Count(sm.Method, sm.Params...) == 0 {
 		closest = spy.ClosestMatch(sm.Method, sm.Params...)
 	}
 
@@ -105,11 +171,11 @@ func (sm *HaveCallMatcher) FailureMessage(actual interface{}) (message string) {
 
 // NegatedFailureMessage returns an explanation of the method call that was unexpected.
 func (sm *HaveCallMatcher) NegatedFailureMessage(actual interface{}) (message string) {
-	spy := types.FindSpy(reflect.ValueOf(actual))
+	spy := sm.spy(actual)
 	if spy == nil {
 		return fmt.Sprintf("Cannot spy on %T", actual)
 	}
-	matched := spy.Count(sm.Method, sm.Params...)
+	matched := sm.observed(actual).Count(sm.Method, sm.Params...)
 	return sm.describe("Did not expect", matched, nil)
 }
 
@@ -119,9 +185,73 @@ func (sm *HaveCallMatcher) With(params ...interface{}) *HaveCallMatcher {
 	return sm
 }
 
-// Times adds an expectation about the number of times a method was called.
+// Returning adds an expectation that matching calls returned the specified
+// results; as with With, each result may be a literal value or a matcher.
+func (sm *HaveCallMatcher) Returning(results ...interface{}) *HaveCallMatcher {
+	sm.Results = types.MatchParams(results)
+	sm.Panics, sm.PanicReason = false, nil
+	return sm
+}
+
+// Panicking adds an expectation that matching calls panicked. If a reason is
+// specified (as a literal value or a matcher), the panic value must match it.
+func (sm *HaveCallMatcher) Panicking(reason ...interface{}) *HaveCallMatcher {
+	sm.Panics, sm.Results = true, nil
+	sm.PanicReason = nil
+	if len(reason) > 0 {
+		sm.PanicReason = types.MatchParams(reason[:1])[0]
+	}
+	return sm
+}
+
+// TakingLessThan adds an expectation that matching calls completed in less
+// than the specified duration.
+func (sm *HaveCallMatcher) TakingLessThan(d time.Duration) *HaveCallMatcher {
+	sm.Within = d
+	return sm
+}
+
+// On specifies which test double to spy on, overriding the actual value that
+// is passed to the matcher.
+func (sm *HaveCallMatcher) On(double interface{}) *HaveCallMatcher {
+	sm.Double = double
+	return sm
+}
+
+// Times adds an expectation that a method was called exactly the specified
+// number of times.
 func (sm *HaveCallMatcher) Times(number int) *HaveCallMatcher {
+	return sm.Between(number, number)
+}
+
+// AtLeast adds an expectation that a method was called the specified number
+// of times or more. It panics with a DSLMisuseError if number is negative.
+func (sm *HaveCallMatcher) AtLeast(number int) *HaveCallMatcher {
+	if number < 0 {
+		misuse("AtLeast", "call count must not be negative; got %d", number)
+	}
 	sm.Count = number
+	sm.max, sm.bounded = 0, false
+	return sm
+}
+
+// AtMost adds an expectation that a method was called the specified number
+// of times or fewer, including never.
+func (sm *HaveCallMatcher) AtMost(number int) *HaveCallMatcher {
+	return sm.Between(0, number)
+}
+
+// Between adds an expectation that a method was called at least lo times
+// and at most hi times (inclusive). It panics with a DSLMisuseError if either
+// bound is negative or lo exceeds hi.
+func (sm *HaveCallMatcher) Between(lo, hi int) *HaveCallMatcher {
+	if lo < 0 || hi < 0 {
+		misuse("Between", "call counts must not be negative; got %d and %d", lo, hi)
+	} else if lo > hi {
+		misuse("Between", "minimum call count %d exceeds maximum %d", lo, hi)
+	}
+	sm.Count = lo
+	sm.max, sm.bounded = hi, true
 	return sm
 }
 
@@ -140,33 +270,90 @@ func (sm *HaveCallMatcher) Twice() *HaveCallMatcher {
 	return sm.Times(2)
 }
 
-func (sm *HaveCallMatcher) describe(lede string, got int, closest []interface{}) string {
-	var ecalls, gcalls string
-	if sm.Count == 1 {
-		ecalls = "call"
-	} else {
-		ecalls = "calls"
+// Determine whether the number of matching calls falls within our bounds.
+func (sm *HaveCallMatcher) satisfied(got int) bool {
+	if got < sm.Count {
+		return false
 	}
+	return !sm.bounded || got <= sm.max
+}
 
-	if got == 1 {
-		gcalls = "call"
-	} else {
-		gcalls = "calls"
+// Returns a phrase describing the expected number of calls, e.g. "at least 2
+// calls" or "between 1 and 3 calls".
+func (sm *HaveCallMatcher) expectation() string {
+	if !sm.bounded {
+		return countPhrase(sm.Count, -1)
 	}
+	return countPhrase(sm.Count, sm.max)
+}
 
-	b := bytes.NewBufferString(fmt.Sprintf("%s %d %s to %s", lede, sm.Count, ecalls, sm.Method))
+// Returns a phrase describing a range of call counts; a negative max means
+// there is no upper bound.
+func countPhrase(min, max int) string {
+	switch {
+	case max < 0:
+		return fmt.Sprintf("at least %d %s", min, pluralize(min))
+	case min == max:
+		return fmt.Sprintf("%d %s", min, pluralize(min))
+	case min == 0:
+		return fmt.Sprintf("at most %d %s", max, pluralize(max))
+	default:
+		return fmt.Sprintf("between %d and %d calls", min, max)
+	}
+}
+
+// Panics with a types.DSLMisuseError.
+func misuse(method, format string, args ...interface{}) {
+	panic(&types.DSLMisuseError{Method: method, Reason: fmt.Sprintf(format, args...)})
+}
+
+func pluralize(n int) string {
+	if n == 1 {
+		return "call"
+	}
+	return "calls"
+}
+
+func (sm *HaveCallMatcher) describe(lede string, got int, closest []interface{}) string {
+	b := bytes.NewBufferString(fmt.Sprintf("%s %s to %s", lede, sm.expectation(), sm.Method))
+	// Separates phrases, unless the previous one ended with a list of matchers.
+	sep := func() {
+		if !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+			b.WriteString(" ")
+		}
+	}
 	if len(sm.Params) > 0 {
 		b.WriteString(" with:\n")
 		formatMatcherInfo(b, 2, sm.Params)
-	} else {
-		b.WriteString(" ")
 	}
+	if sm.Results != nil {
+		sep()
+		b.WriteString("returning:\n")
+		formatMatcherInfo(b, 2, sm.Results)
+	}
+	if sm.Panics {
+		sep()
+		b.WriteString("panicking")
+		if sm.PanicReason != nil {
+			b.WriteString(" with " + matcherString(sm.PanicReason))
+		}
+	}
+	if sm.Within > 0 {
+		sep()
+		b.WriteString(fmt.Sprintf("taking less than %s", sm.Within))
+	}
+	sep()
 
 	if got == 0 && closest != nil {
 		b.WriteString("but no call matched exactly. Closest match:\n")
 		formatParamInfo(b, 2, closest)
 	} else {
-		b.WriteString(fmt.Sprintf("but observed %d %s", got, gcalls))
+		b.WriteString(fmt.Sprintf("but observed %d %s", got, pluralize(got)))
+		if got < sm.Count {
+			b.WriteString(fmt.Sprintf(", fewer than the minimum of %d", sm.Count))
+		} else if sm.bounded && got > sm.max {
+			b.WriteString(fmt.Sprintf(", more than the maximum of %d", sm.max))
+		}
 	}
 	return b.String()
 }
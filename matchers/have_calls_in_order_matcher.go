@@ -0,0 +1,203 @@
+package matchers
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/xeger/gomuti/types"
+)
+
+// HaveCallsInOrderMatcher verifies that a sequence of method calls happened
+// in a specified relative order. Each step is a HaveCallMatcher; steps that
+// name a Double (see HaveCallMatcher.On) consult that double's spy, while the
+// others consult the actual value being matched. This makes it possible to
+// verify ordering across several test doubles.
+//
+// Each step must be satisfied by Count matching calls (one, for steps created
+// with HaveCall) that were all observed after the calls that satisfied the
+// previous step. Only calls whose outcome satisfies the step (see
+// HaveCallMatcher.Returning, Panicking and TakingLessThan) count. Upper bounds
+// on the steps' call counts are not considered.
+type HaveCallsInOrderMatcher struct {
+	Steps []*HaveCallMatcher
+}
+
+// Match verifies that the steps happened in order.
+func (om *HaveCallsInOrderMatcher) Match(actual interface{}) (bool, error) {
+	if len(om.Steps) == 0 {
+		return false, fmt.Errorf("HaveCallsInOrder requires at least one step")
+	}
+	return om.firstUnsatisfied(actual) < 0, nil
+}
+
+// FailureMessage returns an explanation of the call order that was expected,
+// and the order in which calls actually happened.
+func (om *HaveCallsInOrderMatcher) FailureMessage(actual interface{}) string {
+	b := bytes.NewBufferString("Expected calls in order:\n")
+	om.formatSteps(b, 2)
+
+	failed := om.firstUnsatisfied(actual)
+	if failed == 0 {
+		b.WriteString(fmt.Sprintf("but step 0 (%s) was never satisfied", om.Steps[0].Method))
+	} else if failed > 0 {
+		b.WriteString(fmt.Sprintf("but step %d (%s) was not satisfied after step %d (%s)",
+			failed, om.Steps[failed].Method, failed-1, om.Steps[failed-1].Method))
+	}
+	b.WriteString(". Observed timeline:\n")
+	om.formatTimeline(b, 2, actual)
+	return b.String()
+}
+
+// NegatedFailureMessage returns an explanation of the call order that was
+// unexpected.
+func (om *HaveCallsInOrderMatcher) NegatedFailureMessage(actual interface{}) string {
+	b := bytes.NewBufferString("Did not expect calls in order:\n")
+	om.formatSteps(b, 2)
+	b.WriteString("but they happened. Observed timeline:\n")
+	om.formatTimeline(b, 2, actual)
+	return b.String()
+}
+
+// Returns the index of the first step that could not be satisfied, or -1 if
+// all steps were satisfied. Matches each step greedily against the earliest
+// eligible calls, which finds an ordering if one exists.
+func (om *HaveCallsInOrderMatcher) firstUnsatisfied(actual interface{}) int {
+	var cursor uint64
+	for i, step := range om.Steps {
+		need := step.Count
+		for _, seq := range step.observed(actual).Sequence(step.Method, step.Params...) {
+			if need <= 0 {
+				break
+			}
+			if seq > cursor {
+				cursor = seq
+				need--
+			}
+		}
+		if need > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the distinct spies consulted by this matcher, in the order they are
+// first mentioned, paired with the test doubles that contain them.
+func (om *HaveCallsInOrderMatcher) spies(actual interface{}) ([]types.Spy, []interface{}) {
+	var spies []types.Spy
+	var doubles []interface{}
+	seen := map[uintptr]bool{}
+	for _, step := range om.Steps {
+		double := actual
+		if step.Double != nil {
+			double = step.Double
+		}
+		spy := step.spy(actual)
+		id := reflect.ValueOf(spy).Pointer()
+		if !seen[id] {
+			seen[id] = true
+			spies = append(spies, spy)
+			doubles = append(doubles, double)
+		}
+	}
+	return spies, doubles
+}
+
+// Writes a description of each step, including any constraints on the
+// outcome of its calls.
+func (om *HaveCallsInOrderMatcher) formatSteps(b *bytes.Buffer, indent int) {
+	spacer := strings.Repeat(" ", indent)
+	for i, step := range om.Steps {
+		b.WriteString(fmt.Sprintf("%s%2d: %s", spacer, i, step.Method))
+		if step.Count != 1 {
+			b.WriteString(fmt.Sprintf(" (%d %s)", step.Count, pluralize(step.Count)))
+		}
+		// Separates phrases, unless the previous one ended with a list of
+		// matchers.
+		sep := func() {
+			if bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+				b.WriteString(spacer + "    ")
+			} else {
+				b.WriteString(" ")
+			}
+		}
+		if len(step.Params) > 0 {
+			b.WriteString(" with:\n")
+			formatMatcherInfo(b, indent+6, step.Params)
+		}
+		if step.Results != nil {
+			sep()
+			b.WriteString("returning:\n")
+			formatMatcherInfo(b, indent+6, step.Results)
+		}
+		if step.Panics {
+			sep()
+			b.WriteString("panicking")
+			if step.PanicReason != nil {
+				b.WriteString(" with " + matcherString(step.PanicReason))
+			}
+		}
+		if step.Within > 0 {
+			sep()
+			b.WriteString(fmt.Sprintf("taking less than %s", step.Within))
+		}
+		if !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+			b.WriteString("\n")
+		}
+	}
+}
+
+// Writes every call observed by the consulted spies, interleaved in the
+// order they happened. When several doubles are involved, each call is
+// prefixed with the type of the double that received it (and with an index,
+// if several doubles have the same type).
+func (om *HaveCallsInOrderMatcher) formatTimeline(b *bytes.Buffer, indent int, actual interface{}) {
+	spacer := strings.Repeat(" ", indent)
+	spies, doubles := om.spies(actual)
+
+	labels := make([]string, len(doubles))
+	counts := map[string]int{}
+	for i, d := range doubles {
+		labels[i] = fmt.Sprintf("%T", d)
+		counts[labels[i]]++
+	}
+	for i := range labels {
+		if counts[labels[i]] > 1 {
+			labels[i] = fmt.Sprintf("%s#%d", labels[i], i)
+		}
+	}
+
+	type entry struct {
+		types.RecordedCall
+		label string
+	}
+	var timeline []entry
+	for i, spy := range spies {
+		for _, rc := range spy.AllCalls() {
+			timeline = append(timeline, entry{rc, labels[i]})
+		}
+	}
+	sort.Slice(timeline, func(i, j int) bool { return timeline[i].Seq < timeline[j].Seq })
+
+	if len(timeline) == 0 {
+		b.WriteString(spacer + "(no calls)\n")
+	}
+	for _, e := range timeline {
+		prefix := ""
+		if len(spies) > 1 {
+			prefix = e.label + "."
+		}
+		params := make([]string, len(e.Params))
+		for i, p := range e.Params {
+			params[i] = fmt.Sprintf("%#v", p)
+		}
+		line := fmt.Sprintf("%s%s(%s)", prefix, e.Method, strings.Join(params, ", "))
+		if len(line) > 72 {
+			line = fmt.Sprintf("%71.71s…", line)
+		}
+		b.WriteString(fmt.Sprintf("%s%4d: %s\n", spacer, e.Seq, line))
+	}
+}
@@ -0,0 +1,63 @@
+package matchers
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/xeger/gomuti/types"
+)
+
+// HaveMetExpectationsMatcher consults the mock of a test double in order to
+// verify that every required call (see types.Allowed.Required) was matched
+// the expected number of times.
+type HaveMetExpectationsMatcher struct {
+}
+
+// Match verifies that no required call is under- or over-satisfied.
+func (em *HaveMetExpectationsMatcher) Match(actual interface{}) (bool, error) {
+	mock := types.FindMock(reflect.ValueOf(actual))
+	return len(mock.Unsatisfied()) == 0, nil
+}
+
+// FailureMessage returns an explanation of every required call that was
+// matched too few or too many times.
+func (em *HaveMetExpectationsMatcher) FailureMessage(actual interface{}) string {
+	mock := types.FindMock(reflect.ValueOf(actual))
+	b := bytes.NewBufferString("Expected required calls to be satisfied, but:\n")
+	formatUnsatisfied(b, 2, mock.Unsatisfied())
+	return b.String()
+}
+
+// NegatedFailureMessage returns an explanation of why the matcher matched.
+func (em *HaveMetExpectationsMatcher) NegatedFailureMessage(actual interface{}) string {
+	return "Expected some required calls to be unsatisfied, but all were satisfied"
+}
+
+// Writes a description of each unsatisfied call, grouped by method name and
+// sorted alphabetically. Indents each line the specified number of spaces.
+func formatUnsatisfied(b *bytes.Buffer, indent int, unsatisfied map[string][]types.Call) string {
+	methods := make([]string, 0, len(unsatisfied))
+	for method := range unsatisfied {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	spacer := strings.Repeat(" ", indent)
+	for _, method := range methods {
+		for _, c := range unsatisfied[method] {
+			got := c.Matched()
+			b.WriteString(fmt.Sprintf("%s%s: expected %s but matched %d %s",
+				spacer, method, countPhrase(c.MinTimes, c.MaxTimes), got, pluralize(got)))
+			if len(c.Params) > 0 {
+				b.WriteString(" with:\n")
+				formatMatcherInfo(b, indent+2, c.Params)
+			} else {
+				b.WriteString(" with any parameters\n")
+			}
+		}
+	}
+	return b.String()
+}
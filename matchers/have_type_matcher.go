@@ -3,7 +3,7 @@ package matchers
 import (
 	"reflect"
 
-	"github.com/onsi/gomega/format"
+	"github.com/xeger/gomuti/types"
 )
 
 // HaveTypeMatcher matches any value whose type matches the specified name.
@@ -23,10 +23,10 @@ func (m *HaveTypeMatcher) Match(actual interface{}) (bool, error) {
 
 // FailureMessage returns a description of why the matcher did not match.
 func (m *HaveTypeMatcher) FailureMessage(actual interface{}) string {
-	return format.Message(actual, "to have type", m.Expected)
+	return types.FormatMessage(actual, "to have type", m.Expected)
 }
 
 // NegatedFailureMessage returns a description of why the matcher matched.
 func (m *HaveTypeMatcher) NegatedFailureMessage(actual interface{}) string {
-	return format.Message(actual, "not to have type", m.Expected)
+	return types.FormatMessage(actual, "not to have type", m.Expected)
 }
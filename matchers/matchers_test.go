@@ -1,8 +1,11 @@
 package matchers_test
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	. "github.com/xeger/gomuti"
 	"github.com/xeger/gomuti/matchers"
 	"github.com/xeger/gomuti/types"
 )
@@ -57,4 +60,167 @@ var _ = Describe("HaveCallMatcher", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
+
+	Context("call counts", func() {
+		var o infiltrated
+
+		BeforeEach(func() {
+			o = infiltrated{Espion: types.Spy{}}
+			o.Espion.Observe("SecretMeeting")
+			o.Espion.Observe("SecretMeeting")
+			o.Espion.Observe("SecretMeeting")
+		})
+
+		It("defaults to at least once", func() {
+			Expect(o).To(HaveCall("SecretMeeting"))
+			Expect(o).NotTo(HaveCall("Ambush"))
+		})
+
+		It("treats Times as an exact count", func() {
+			Expect(o).To(HaveCall("SecretMeeting").Times(3))
+			Expect(o).NotTo(HaveCall("SecretMeeting").Times(2))
+			Expect(o).NotTo(HaveCall("SecretMeeting").Once())
+			Expect(o).NotTo(HaveCall("SecretMeeting").Never())
+			Expect(o).To(HaveCall("Ambush").Never())
+		})
+
+		It("supports AtLeast", func() {
+			Expect(o).To(HaveCall("SecretMeeting").AtLeast(2))
+			Expect(o).To(HaveCall("SecretMeeting").AtLeast(3))
+			Expect(o).NotTo(HaveCall("SecretMeeting").AtLeast(4))
+		})
+
+		It("supports AtMost", func() {
+			Expect(o).To(HaveCall("SecretMeeting").AtMost(4))
+			Expect(o).To(HaveCall("SecretMeeting").AtMost(3))
+			Expect(o).NotTo(HaveCall("SecretMeeting").AtMost(2))
+			Expect(o).To(HaveCall("Ambush").AtMost(1))
+		})
+
+		It("supports Between", func() {
+			Expect(o).To(HaveCall("SecretMeeting").Between(1, 3))
+			Expect(o).To(HaveCall("SecretMeeting").Between(3, 5))
+			Expect(o).NotTo(HaveCall("SecretMeeting").Between(4, 5))
+			Expect(o).NotTo(HaveCall("SecretMeeting").Between(0, 2))
+		})
+
+		It("rejects impossible bounds", func() {
+			misuse := PanicWith(BeAssignableToTypeOf(&types.DSLMisuseError{}))
+			Expect(func() { HaveCall("SecretMeeting").AtLeast(-1) }).To(misuse)
+			Expect(func() { HaveCall("SecretMeeting").AtMost(-1) }).To(misuse)
+			Expect(func() { HaveCall("SecretMeeting").Times(-2) }).To(misuse)
+			Expect(func() { HaveCall("SecretMeeting").Between(-1, 2) }).To(misuse)
+			Expect(func() { HaveCall("SecretMeeting").Between(3, 2) }).To(misuse)
+			Expect(func() { HaveCall("SecretMeeting").Between(2, 2) }).NotTo(Panic())
+		})
+
+		It("explains which bound was broken", func() {
+			msg := HaveCall("SecretMeeting").AtLeast(4).FailureMessage(o)
+			Expect(msg).To(ContainSubstring("at least 4 calls"))
+			Expect(msg).To(ContainSubstring("fewer than the minimum of 4"))
+
+			msg = HaveCall("SecretMeeting").AtMost(2).FailureMessage(o)
+			Expect(msg).To(ContainSubstring("at most 2 calls"))
+			Expect(msg).To(ContainSubstring("more than the maximum of 2"))
+
+			msg = HaveCall("SecretMeeting").Once().FailureMessage(o)
+			Expect(msg).To(ContainSubstring("Expected 1 call to SecretMeeting"))
+			Expect(msg).To(ContainSubstring("more than the maximum of 1"))
+
+			msg = HaveCall("SecretMeeting").Between(4, 5).FailureMessage(o)
+			Expect(msg).To(ContainSubstring("between 4 and 5 calls"))
+			Expect(msg).To(ContainSubstring("fewer than the minimum of 4"))
+		})
+	})
+
+	Context("outcomes", func() {
+		var o infiltrated
+
+		BeforeEach(func() {
+			o = infiltrated{Espion: types.Spy{}}
+			o.Espion.Begin("Decode", "abc").Return("xyz", nil)
+			o.Espion.Begin("Decode", "???").Panic("gibberish")
+			o.Espion.Begin("Decode", "...")
+			o.Espion.Observe("Decode", "old")
+		})
+
+		It("matches results", func() {
+			Expect(o).To(HaveCall("Decode").Returning("xyz", nil).Once())
+			Expect(o).To(HaveCall("Decode").With("abc").Returning(HavePrefix("x"), BeNil()))
+			Expect(o).NotTo(HaveCall("Decode").Returning("abc", nil))
+			Expect(o).NotTo(HaveCall("Decode").Returning("xyz"))
+		})
+
+		It("matches panics", func() {
+			Expect(o).To(HaveCall("Decode").Panicking().Once())
+			Expect(o).To(HaveCall("Decode").With("???").Panicking("gibberish"))
+			Expect(o).NotTo(HaveCall("Decode").Panicking(ContainSubstring("nonsense")))
+		})
+
+		It("matches durations", func() {
+			Expect(o).To(HaveCall("Decode").TakingLessThan(time.Minute).Twice())
+			Expect(o).NotTo(HaveCall("Decode").TakingLessThan(time.Nanosecond))
+		})
+
+		It("describes the expected outcome", func() {
+			msg := HaveCall("Decode").Returning("abc", nil).TakingLessThan(time.Second).FailureMessage(o)
+			Expect(msg).To(ContainSubstring("Decode returning:\n"))
+			Expect(msg).To(ContainSubstring("taking less than 1s but observed 0 calls"))
+
+			msg = HaveCall("Decode").With("abc").Panicking("oops").FailureMessage(o)
+			Expect(msg).To(ContainSubstring("panicking with Equal(\"oops\") but observed 0 calls"))
+		})
+	})
 })
+
+var _ = Describe("HaveCallsInOrderMatcher", func() {
+	var file, log infiltrated
+
+	BeforeEach(func() {
+		file = infiltrated{Espion: types.Spy{}}
+		log = infiltrated{Espion: types.Spy{}}
+		file.Espion.Observe("Open", "secrets.txt")
+		log.Espion.Observe("Info", "opened")
+		file.Espion.Observe("Write", "attack at dawn")
+		file.Espion.Observe("Write", "bring snacks")
+		file.Espion.Observe("Close")
+	})
+
+	It("verifies order within one spy", func() {
+		Expect(file).To(HaveCallsInOrder(HaveCall("Open"), HaveCall("Write"), HaveCall("Close")))
+		Expect(file).To(HaveCallsInOrder(HaveCall("Open"), HaveCall("Write").Twice(), HaveCall("Close")))
+		Expect(file).To(HaveCallsInOrder(HaveCall("Write").With("bring snacks"), HaveCall("Close")))
+		Expect(file).NotTo(HaveCallsInOrder(HaveCall("Close"), HaveCall("Open")))
+		Expect(file).NotTo(HaveCallsInOrder(HaveCall("Write").With("bring snacks"), HaveCall("Write").With("attack at dawn")))
+		Expect(file).NotTo(HaveCallsInOrder(HaveCall("Open"), HaveCall("Write").Times(3)))
+	})
+
+	It("verifies order across spies", func() {
+		Expect(file).To(HaveCallsInOrder(HaveCall("Open"), HaveCall("Info").On(log), HaveCall("Write")))
+		Expect(file).NotTo(HaveCallsInOrder(HaveCall("Write"), HaveCall("Info").On(log)))
+	})
+
+	It("considers the outcome of each step", func() {
+		db := infiltrated{Espion: types.Spy{}}
+		db.Espion.Begin("Exec", "INSERT").Panic("deadlock")
+		db.Espion.Begin("Commit").Return(nil)
+		db.Espion.Begin("Exec", "INSERT").Return(1, nil)
+
+		Expect(db).To(HaveCallsInOrder(HaveCall("Exec"), HaveCall("Commit")))
+		Expect(db).To(HaveCallsInOrder(HaveCall("Exec").Panicking(), HaveCall("Commit").Returning(nil)))
+		Expect(db).NotTo(HaveCallsInOrder(HaveCall("Exec").Returning(1, nil), HaveCall("Commit")))
+		Expect(db).NotTo(HaveCallsInOrder(HaveCall("Exec").Panicking("timeout"), HaveCall("Commit")))
+
+		msg := HaveCallsInOrder(HaveCall("Exec").Returning(1, nil), HaveCall("Commit")).FailureMessage(db)
+		Expect(msg).To(ContainSubstring("0: Exec returning:"))
+		Expect(msg).To(ContainSubstring("step 1 (Commit) was not satisfied after step 0 (Exec)"))
+	})
+
+	It("shows the interleaved timeline on failure", func() {
+		m := HaveCallsInOrder(HaveCall("Close"), HaveCall("Info").On(log))
+		msg := m.FailureMessage(file)
+		Expect(msg).To(ContainSubstring("step 1 (Info) was not satisfied after step 0 (Close)"))
+		Expect(msg).To(MatchRegexp(`(?s)Open\("secrets.txt"\).*Info\("opened"\).*Write\("attack at dawn"\).*Close\(\)`))
+	})
+})
+
@@ -0,0 +1,97 @@
+package matchers
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/xeger/gomuti/types"
+)
+
+// The functions below create Gomuti's own parameter matchers, which work
+// with Allow, HaveCall and gomutitest without Gomega. Because their names
+// clash with Gomega's, use them through the package name:
+//
+//     Allow(logger).Call("Log").With(matchers.Regexp("^user"), matchers.Numerically(">", 0))
+//
+// And, Or and Not accept literal values in place of matchers, as With does.
+
+// Equal matches values that are deeply equal to expected.
+func Equal(expected interface{}) *types.EqualMatcher {
+	return &types.EqualMatcher{Expected: expected}
+}
+
+// Equivalent matches values that are deeply equal to expected after they have
+// been converted to its type, if possible.
+func Equivalent(expected interface{}) *types.EquivalentMatcher {
+	return &types.EquivalentMatcher{Expected: expected}
+}
+
+// Nil matches nil, and nil pointers, slices, maps, channels and functions.
+func Nil() *types.NilMatcher {
+	return &types.NilMatcher{}
+}
+
+// Any matches any value, including nil.
+func Any() *types.AnyMatcher {
+	return &types.AnyMatcher{}
+}
+
+// TypeOf matches non-nil values that are assignable to T, which may be an
+// interface type:
+//
+//     Allow(store).Call("Put").With(matchers.TypeOf[string](), matchers.TypeOf[io.Reader]())
+func TypeOf[T any]() *types.TypeOfMatcher {
+	return &types.TypeOfMatcher{Expected: reflect.TypeOf((*T)(nil)).Elem()}
+}
+
+// And matches values that satisfy every one of several matchers.
+func And(ms ...interface{}) *types.AndMatcher {
+	return &types.AndMatcher{Matchers: types.MatchParams(ms)}
+}
+
+// Or matches values that satisfy at least one of several matchers.
+func Or(ms ...interface{}) *types.OrMatcher {
+	return &types.OrMatcher{Matchers: types.MatchParams(ms)}
+}
+
+// Not matches values that a matcher does not match.
+func Not(m interface{}) *types.NotMatcher {
+	return &types.NotMatcher{Matcher: types.MatchParams([]interface{}{m})[0]}
+}
+
+// Regexp matches strings, byte slices and fmt.Stringers that contain a match
+// for a regular expression. It panics with a DSLMisuseError if the expression
+// is invalid.
+func Regexp(expr string) *types.RegexpMatcher {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		panic(&types.DSLMisuseError{Method: "Regexp", Reason: err.Error()})
+	}
+	return &types.RegexpMatcher{Expected: re}
+}
+
+// Numerically matches numbers of any type that compare to a number as
+// specified by comparator, which is one of "==", "!=", "<", "<=", ">", ">="
+// or "~" (approximately equal, to within an optional threshold that follows
+// the number):
+//
+//     matchers.Numerically(">=", 1)
+//     matchers.Numerically("~", 3.14, 0.01)
+//
+// It panics with a DSLMisuseError if the comparator is unknown.
+func Numerically(comparator string, compareTo ...interface{}) *types.NumericallyMatcher {
+	switch comparator {
+	case "==", "!=", "<", "<=", ">", ">=":
+		if len(compareTo) != 1 {
+			panic(&types.DSLMisuseError{Method: "Numerically", Reason: fmt.Sprintf("Numerically(%q) needs one number to compare to", comparator)})
+		}
+	case "~":
+		if len(compareTo) != 1 && len(compareTo) != 2 {
+			panic(&types.DSLMisuseError{Method: "Numerically", Reason: `Numerically("~") needs a number to compare to, and optionally a threshold`})
+		}
+	default:
+		panic(&types.DSLMisuseError{Method: "Numerically", Reason: fmt.Sprintf("unknown comparator %q", comparator)})
+	}
+	return &types.NumericallyMatcher{Comparator: comparator, CompareTo: compareTo}
+}
@@ -0,0 +1,146 @@
+package matchers_test
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/xeger/gomuti"
+	"github.com/xeger/gomuti/matchers"
+	"github.com/xeger/gomuti/types"
+)
+
+var _ = Describe("native matchers", func() {
+	// Reports whether a matcher matches a value as a param of a mocked call
+	// would be matched, i.e. after widening.
+	matches := func(m types.Matcher, actual interface{}) bool {
+		mock := types.Mock{}
+		mock.Allow().Call("Foo").With(m).Return(true)
+		return mock.Call("Foo", actual) != nil
+	}
+
+	It("Equal", func() {
+		Expect(matches(matchers.Equal(5), 5)).To(BeTrue())
+		Expect(matches(matchers.Equal(int8(5)), int8(5))).To(BeTrue())
+		Expect(matches(matchers.Equal(int32(5)), int64(5))).To(BeTrue())
+		Expect(matches(matchers.Equal(5), 6)).To(BeFalse())
+		Expect(matches(matchers.Equal([]int{1}), []int{1})).To(BeTrue())
+		Expect(matchers.Equal(5).FailureMessage(6)).To(Equal("Expected\n    <int>: 6\nto equal\n    <int>: 5"))
+	})
+
+	It("Equivalent", func() {
+		type celsius float64
+		Expect(matches(matchers.Equivalent(celsius(20)), 20.0)).To(BeTrue())
+		Expect(matches(matchers.Equivalent(int32(65)), int64(65))).To(BeTrue())
+		Expect(matches(matchers.Equivalent("A"), 65)).To(BeFalse())
+		Expect(matches(matchers.Equivalent([1]int{1}), []int{})).To(BeFalse())
+	})
+
+	It("Nil", func() {
+		var p *int
+		Expect(matches(matchers.Nil(), nil)).To(BeTrue())
+		Expect(matches(matchers.Nil(), p)).To(BeTrue())
+		Expect(matches(matchers.Nil(), 0)).To(BeFalse())
+	})
+
+	It("Any", func() {
+		Expect(matches(matchers.Any(), nil)).To(BeTrue())
+		Expect(matches(matchers.Any(), "x")).To(BeTrue())
+	})
+
+	It("TypeOf", func() {
+		Expect(matches(matchers.TypeOf[int32](), int32(1))).To(BeTrue())
+		Expect(matches(matchers.TypeOf[int64](), int32(1))).To(BeFalse())
+		Expect(matches(matchers.TypeOf[io.Reader](), strings.NewReader(""))).To(BeTrue())
+		Expect(matches(matchers.TypeOf[error](), nil)).To(BeFalse())
+	})
+
+	It("And, Or and Not", func() {
+		positive := matchers.Numerically(">", 0)
+		Expect(matches(matchers.And(positive, matchers.Not(3)), 2)).To(BeTrue())
+		Expect(matches(matchers.And(positive, matchers.Not(3)), 3)).To(BeFalse())
+		Expect(matches(matchers.Or("a", "b"), "b")).To(BeTrue())
+		Expect(matches(matchers.Or("a", "b"), "c")).To(BeFalse())
+
+		Expect(matchers.And(positive, 5).FailureMessage(-1)).To(ContainSubstring("to be >"))
+	})
+
+	It("Regexp", func() {
+		Expect(matches(matchers.Regexp("^us"), "user")).To(BeTrue())
+		Expect(matches(matchers.Regexp("^us"), []byte("user"))).To(BeTrue())
+		Expect(matches(matchers.Regexp("^1s$"), time.Second)).To(BeTrue())
+		Expect(matches(matchers.Regexp("^us"), 42)).To(BeFalse())
+		Expect(func() {
+			matchers.Regexp("(")
+		}).To(PanicWith(BeAssignableToTypeOf(&types.DSLMisuseError{})))
+	})
+
+	It("Numerically", func() {
+		Expect(matches(matchers.Numerically(">", 1), uint8(2))).To(BeTrue())
+		Expect(matches(matchers.Numerically("<=", 2.5), 2)).To(BeTrue())
+		Expect(matches(matchers.Numerically("<", uint64(1)), -1)).To(BeTrue())
+		Expect(matches(matchers.Numerically("==", uint64(1<<63)), int64(-1))).To(BeFalse())
+		Expect(matches(matchers.Numerically("~", 3.14, 0.01), 3.141)).To(BeTrue())
+		Expect(matches(matchers.Numerically("~", 3.14), 3.141)).To(BeFalse())
+		Expect(matches(matchers.Numerically(">", 1), "2")).To(BeFalse())
+		Expect(func() {
+			matchers.Numerically("=>", 1)
+		}).To(PanicWith(BeAssignableToTypeOf(&types.DSLMisuseError{})))
+	})
+
+	It("explains mismatches", func() {
+		mock := types.Mock{}
+		mock.Allow().Call("Foo").With(matchers.Regexp("^a")).Return(true)
+		e := mock.Explain("Foo", "b")
+		Expect(e.Candidates[0].Reason).To(Equal("parameter 0: Expected\n    <string>: \"b\"\nto match regular expression\n    <string>: \"^a\""))
+	})
+
+	It("describes themselves in HaveCall failures", func() {
+		spy := types.Spy{}
+		spy.Observe("Foo", 1)
+		m := HaveCall("Foo").With(matchers.Equal(2))
+		Expect(m.Match(spy)).To(BeFalse())
+		Expect(m.FailureMessage(spy)).To(ContainSubstring("Equal(2)"))
+	})
+
+	It("rank Gomega's matchers as they rank their own", func() {
+		mock := types.Mock{}
+		mock.Allow().Call("Foo").With(Equal("x")).Return("gomega")
+		mock.Allow().Call("Foo").With(matchers.Equivalent("x")).Return("native")
+		Expect(mock.Call("Foo", "x")).To(Equal([]interface{}{"gomega"}))
+
+		mock.Allow().Call("Bar").With(matchers.Equal(1)).Return("native")
+		mock.Allow().Call("Bar").With(BeEquivalentTo(1)).Return("gomega")
+		Expect(mock.Call("Bar", 1)).To(Equal([]interface{}{"native"}))
+
+		mock.Allow().Call("Baz").With(BeEquivalentTo(1)).Return("gomega")
+		mock.Allow().Call("Baz").With(matchers.Numerically(">", 0)).Return("numerically")
+		Expect(mock.Call("Baz", 1)).To(Equal([]interface{}{"gomega"}))
+
+		mock.Allow().Call("Qux").With(Equal(1)).Return("gomega")
+		mock.Allow().Call("Qux").With(Anything()).Return("anything")
+		Expect(mock.Call("Qux", 1)).To(Equal([]interface{}{"gomega"}))
+	})
+
+	It("rank matchers by their weight", func() {
+		mock := types.Mock{}
+		mock.Allow().Call("Foo").With(weighted{Matcher: matchers.Any(), weight: 5}).Return("heavy")
+		mock.Allow().Call("Foo").With(1).Return("literal")
+		Expect(mock.Call("Foo", 1)).To(Equal([]interface{}{"heavy"}))
+
+		mock.Allow().Call("Bar").With(weighted{Matcher: matchers.Any(), weight: -1}).Return("light")
+		Expect(mock.Call("Bar", 1)).To(Equal([]interface{}{"light"}))
+	})
+})
+
+// A matcher of any weight.
+type weighted struct {
+	types.Matcher
+	weight int
+}
+
+func (w weighted) Weight() int {
+	return w.weight
+}
@@ -2,14 +2,18 @@ package types
 
 import (
 	"fmt"
+	"math/rand"
 	"reflect"
+	"sync"
+	"time"
 )
 
 // Allowed is a DSL object that lets you specify parameters, return values
 // and other behaviors for a mocked call. Calls to gomuti.Allow() return
 // this type; each method that you call, refines the mock behavior that you
 // are defining. The behavior is "completed" when you specify an outcome
-// for the mock behavior by calling Return, Panic or Do.
+// for the mock behavior by calling Return, Panic or Do; you can specify a
+// sequence of behaviors by separating them with Then.
 //
 // When your mock receives a method call, it is compared to each call that
 // you have allowed for that method name. Gomuti matches the actual parameters
@@ -26,11 +30,16 @@ import (
 // 2) If the allowed call did not specify any parameters, score = 1
 //    (the allowed call matches any number of actual parameters, but just barely).
 //
-// 3) For each parameter that matches a gomega.BeEqual matcher, score += 4.
+// 3) For each parameter that matches an EqualMatcher or NilMatcher (which
+//    literal values are turned into), or Gomega's Equal or BeNil, score += 4.
 //
-// 4) For each parameter that matches a gomega.BeEquivalentTo matcher, score += 3
+// 4) For each parameter that matches an EquivalentMatcher (or Gomega's
+//    BeEquivalentTo), score += 3
 //
-// 5) For each parameter that matches another matcher, score += 2
+// 5) For each parameter that matches a WeightedMatcher, score += its Weight;
+//    for each parameter that matches another matcher, score += 2
+//
+// Calls that have been exhausted (see Times) are not considered at all.
 //
 // The scoring algorithm sounds complicated, but it results in a very natural-
 // feeling matching behavior. Imagine that we are mocking a method
@@ -46,8 +55,14 @@ import (
 //     double.Add(1,2,3)    // returns 6
 //     double.Add(8,8,8)    // panics with a confused message
 type Allowed struct {
-	mock Mock
-	last string
+	mock  Mock
+	last  string
+	index int
+
+	// type of the test double that contains the mock, if known, and the
+	// signature of its method named by Call, if any
+	double reflect.Type
+	sig    reflect.Type
 }
 
 // Call allows the mock to receive a method call with matching parameters and
@@ -60,13 +75,25 @@ type Allowed struct {
 // If you call this method twice on the same Allowed, gomuti panics.
 func (a *Allowed) Call(method string, params ...interface{}) *Allowed {
 	if a.last != "" {
-		panic("gomuti: cannot use Call() twice on the same Allowed")
+		misuse("Call", "cannot use Call() twice on the same Allowed")
+	}
+	if a.mock == nil {
+		panic(&UninitializedError{Type: "types.Mock", Method: "Allow"})
 	}
 
-	calls := a.mock[method]
-	calls = append(calls, Call{})
-	a.mock[method] = calls
-	a.last = method
+	func() {
+		l := a.mock.lock()
+		l.Lock()
+		defer l.Unlock()
+
+		calls := a.mock[method]
+		calls = append(calls, Call{stats: &callStats{}})
+		a.mock[method] = calls
+		a.last = method
+		a.index = len(calls) - 1
+	}()
+	a.sig = methodSignature(a.double, method)
+
 	if len(params) > 0 {
 		a.With(params...)
 	}
@@ -84,13 +111,14 @@ func (a *Allowed) Call(method string, params ...interface{}) *Allowed {
 //
 // Literal values of basic type (int, bool, string, etc) are converted to
 // equality matchers; literal pointers and interface types (slice, map, etc)
-// are converted to equivalency matchers. Therefore, calling this:
+// are converted to equivalency matchers; nil is converted to a nil matcher.
+// Therefore, calling this:
 //
 //     Â(double, "Foo").With("hello", 42, time.Now())
 //
-// is the same as calling this:
+// is the same as calling this (see package gomuti/matchers):
 //
-//     Â(double, "Foo").With(Equals("hello"), Equals(42), BeEquivalentTo(time.Now()))
+//     Â(double, "Foo").With(matchers.Equal("hello"), matchers.Equal(42), matchers.Equivalent(time.Now()))
 //
 // MATCHING RANGES OF VALUES
 //
@@ -104,23 +132,32 @@ func (a *Allowed) Call(method string, params ...interface{}) *Allowed {
 //
 // MATCHING VARIADIC PARAMETERS
 //
-// Variadic parameters are converted to a slice of values and matched against
-// the final matcher provided to With. For instance:
+// Generated test doubles pass variadic parameters to the Mock as a slice of
+// values, which is matched against the final matcher provided to With. For
+// instance:
 //
 //   Â(double, "Foo").With(true, []int{1,2,3})
 //   double.Foo(true, 1, 2, 3)
 //
+// To match variadic parameters no matter how the double passes them, end With
+// with a RestMatcher, which matches all of the remaining parameters:
+//
+//   Â(double, "Foo").With(true, VariadicWith(1, Anything(), 3))
+//   Â(double, "Foo").With(true, Rest(HaveLen(3)))
+//
 // If you call this method twice on the same Allowed, gomuti panics.
 func (a *Allowed) With(params ...interface{}) *Allowed {
-	calls := a.mock[a.last]
-	if calls == nil || len(calls) < 1 {
-		panic("gomuti: must use Call() before specifying With()")
-	}
-	call := &calls[len(calls)-1]
+	matchers := MatchParams(params)
+
+	l := a.mock.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	call := a.current("With")
 	if call.Params != nil {
-		panic("gomuti: cannot specify With() twice")
+		misuse("With", "cannot specify With() twice")
 	}
-	call.Params = MatchParams(params)
+	call.Params = matchers
 	return a
 }
 
@@ -136,27 +173,117 @@ func do(fn interface{}) CallFunc {
 	}
 
 	v := reflect.ValueOf(fn)
-	switch v.Kind() {
-	case reflect.Func:
-		return func(params ...interface{}) []interface{} {
-			in := make([]reflect.Value, 0, len(params))
-			for _, p := range params {
-				in = append(in, reflect.ValueOf(p))
-			}
-			out := v.Call(in)
-			result := make([]interface{}, 0, len(out))
-			for _, o := range out {
-				if o.CanInterface() {
-					result = append(result, o.Interface())
-				} else {
-					panic(fmt.Sprintf("gomuti: CallFunc adapter cannot handle %s (CanInterface==false)", o.String()))
-				}
+	if v.Kind() != reflect.Func {
+		panic(&BadDoSignatureError{Reason: fmt.Sprintf("cannot convert %T into CallFunc", fn)})
+	}
+	return func(params ...interface{}) []interface{} {
+		in, spread := doParams(v.Type(), params)
+		var out []reflect.Value
+		if spread {
+			out = v.CallSlice(in)
+		} else {
+			out = v.Call(in)
+		}
+		result := make([]interface{}, 0, len(out))
+		for _, o := range out {
+			result = append(result, o.Interface())
+		}
+		return result
+	}
+}
+
+// Converts the params of a method call into arguments for a function that
+// was passed to Do, or panics if the function cannot accept them.
+//
+// If the function is variadic, its final parameter may be passed either as a
+// single slice (as generated test doubles do) or as individual values; the
+// boolean result is true in the former case, meaning that the arguments must
+// be passed to reflect.Value.CallSlice rather than Call.
+func doParams(ft reflect.Type, params []interface{}) ([]reflect.Value, bool) {
+	bad := func(format string, args ...interface{}) {
+		panic(&BadDoSignatureError{Func: ft, Params: params, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	n := ft.NumIn()
+	spread := false
+	if ft.IsVariadic() {
+		if len(params) < n-1 {
+			bad("expected at least %d parameters; got %d", n-1, len(params))
+		}
+		if len(params) == n {
+			last := params[n-1]
+			spread = last == nil || reflect.TypeOf(last).AssignableTo(ft.In(n-1))
+		}
+	} else if len(params) != n {
+		bad("expected %d parameters; got %d", n, len(params))
+	}
+
+	in := make([]reflect.Value, len(params))
+	for i, p := range params {
+		var t reflect.Type
+		if ft.IsVariadic() && i >= n-1 && !spread {
+			t = ft.In(n - 1).Elem()
+		} else {
+			t = ft.In(i)
+		}
+		arg, ok := convertParam(p, t)
+		if !ok {
+			actual := "nil"
+			if p != nil {
+				actual = reflect.TypeOf(p).String()
 			}
-			return result
+			bad("parameter %d is %s, which cannot be used as %s", i, actual, t)
+		}
+		in[i] = arg
+	}
+	return in, spread
+}
+
+// Converts a param to a value of type t, reporting whether that was possible.
+// Nil becomes the zero value of a nillable type. Numbers convert to other
+// numeric types of the same kind (signed, unsigned or floating point) if they
+// fit, which undoes any widening (see widen) that they underwent.
+func convertParam(p interface{}, t reflect.Type) (reflect.Value, bool) {
+	if fits(p, t) {
+		if p == nil {
+			return reflect.Zero(t), true
 		}
-	default:
-		panic(fmt.Sprintf("gomuti: cannot convert %T into CallFunc", fn))
+		return reflect.ValueOf(p), true
 	}
+	if p == nil {
+		return reflect.Value{}, false
+	}
+
+	v := reflect.ValueOf(p)
+	switch numericKind(v.Kind()) {
+	case reflect.Int:
+		if numericKind(t.Kind()) == reflect.Int && !reflect.Zero(t).OverflowInt(v.Int()) {
+			return v.Convert(t), true
+		}
+	case reflect.Uint:
+		if numericKind(t.Kind()) == reflect.Uint && !reflect.Zero(t).OverflowUint(v.Uint()) {
+			return v.Convert(t), true
+		}
+	case reflect.Float64:
+		if numericKind(t.Kind()) == reflect.Float64 && !reflect.Zero(t).OverflowFloat(v.Float()) {
+			return v.Convert(t), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// Classifies numeric kinds as Int, Uint or Float64; returns Invalid for
+// anything else.
+func numericKind(k reflect.Kind) reflect.Kind {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.Int
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.Uint
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	}
+	return reflect.Invalid
 }
 
 // Do allows you to provide a function that the mock will call in order to
@@ -165,53 +292,200 @@ func do(fn interface{}) CallFunc {
 //
 // You can pass any function signature to Do; however, if the signature
 // does not match the signature of the method being mocked, gomuti will cause
-// a panic when you call the mock method. Use this method with care!
+// a panic when you call the mock method (or when you call Do, if Gomuti knows
+// the type of the test double). Use this method with care!
+//
+// Gomuti adapts the method's parameters to the function where it can: nil
+// becomes a typed nil, numbers are converted to the function's numeric types
+// if they fit, and a variadic function may receive its variadic parameters
+// either as one slice, as generated test doubles pass them, or one by one.
+// If Gomuti knows the signature of the method, it converts the function's
+// numeric results to the method's result types in the same way.
 //
 // If you call this method twice on the same Allowed, gomuti panics.
-func (a Allowed) Do(doer interface{}) {
+func (a *Allowed) Do(doer interface{}) *Allowed {
+	a.checkDo(doer)
 	df := do(doer)
-	calls := a.mock[a.last]
-	if calls == nil || len(calls) < 1 {
-		panic("gomuti: must use Call() before specifying Do()")
+	if a.checked(doer) {
+		df = convertResults(a.sig, reflect.TypeOf(doer), df)
 	}
-	call := &calls[len(calls)-1]
-	a.behave(df, call.Panic, call.Results)
+
+	l := a.mock.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	call := a.outcome("Do")
+	a.behave("Do", df, call.Panic, call.Results)
 	if call.Do != nil {
-		panic("gomuti: cannot specify Do() twice")
+		misuse("Do", "cannot specify Do() twice")
 	}
 	call.Do = df
+	return a
+}
+
+// CallThrough causes the mock to forward the call to its delegate (see
+// Mock.SetDelegate), which is useful for partial mocks that override some
+// calls of a real implementation and let the rest pass through:
+//
+//     double := &MockStore{Delegate: realStore}
+//     Allow(double).Call("Get").With("missing").Return(nil, ErrNotFound)
+//     Allow(double).Call("Get").CallThrough()
+//
+// It is a kind of Do, so you cannot combine it with Do, Return or Panic. If
+// the mock has no delegate when the call is matched, it panics.
+func (a *Allowed) CallThrough() *Allowed {
+	mock, method := a.mock, a.last
+
+	return a.Do(CallFunc(func(params ...interface{}) []interface{} {
+		d := mock.getDelegate()
+		if d == nil {
+			misuse("CallThrough", "cannot call %s through to a delegate; the Mock has none", method)
+		}
+		return CallThrough(d, method, params...)
+	}))
 }
 
 // Return specifies what the mock should return when a method call is matched.
 // It must be called after Call/ToReceive.
-func (a Allowed) Return(results ...interface{}) {
-	calls := a.mock[a.last]
-	if calls == nil || len(calls) < 1 {
-		panic("gomuti: must use Call() before specifying Return()")
+//
+// If Gomuti knows the signature of the method (see Mock.AllowFor), numeric
+// results are converted to the method's numeric types if they fit, as with
+// Do; e.g. Return(10) for a method that returns int64. Results that cannot be
+// returned by the method panic with a SignatureError.
+func (a *Allowed) Return(results ...interface{}) *Allowed {
+	if results == nil {
+		results = []interface{}{}
+	}
+	if a.sig != nil {
+		results = convertReturns(a.last, a.sig, results)
 	}
-	call := &calls[len(calls)-1]
-	a.behave(call.Do, call.Panic, results)
+
+	l := a.mock.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	call := a.outcome("Return")
+	a.behave("Return", call.Do, call.Panic, results)
 	if call.Results != nil {
-		panic("gomuti: cannot specify Return() twice")
+		misuse("Return", "cannot specify Return() twice")
 	}
 	call.Results = results
-	return
+	return a
 }
 
 // Panic specifies that the mock should panic with the given reason when
 // a method call is matched. It must be called after Call/ToReceive.
-func (a Allowed) Panic(reason interface{}) {
-	calls := a.mock[a.last]
-	if calls == nil || len(calls) < 1 {
-		panic("mock: must use Call() before specifying Panic()")
-	}
-	call := &calls[len(calls)-1]
-	a.behave(call.Do, reason, call.Results)
+func (a *Allowed) Panic(reason interface{}) *Allowed {
+	l := a.mock.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	call := a.outcome("Panic")
+	a.behave("Panic", call.Do, reason, call.Results)
 	if call.Panic != nil {
-		panic("gomuti: cannot specify Panic() twice")
+		misuse("Panic", "cannot specify Panic() twice")
 	}
 	call.Panic = reason
-	return
+	return a
+}
+
+// Delay causes the mock to wait before the call behaves as programmed with
+// Return, Panic or Do, which is useful for testing timeouts. The mock waits
+// using its Clock (see Mock.SetClock), so tests need not really sleep. If a
+// param of the call is a context.Context, the mock stops waiting when the
+// context is cancelled.
+//
+// Like Return, Delay applies to the current behavior; after Then, you may
+// specify a different delay.
+func (a *Allowed) Delay(d time.Duration) *Allowed {
+	return a.delay("Delay", func() time.Duration { return d })
+}
+
+// DelayRandom is like Delay, but waits for a random duration of at least min
+// and less than max. The durations come from a pseudo-random sequence that is
+// determined by seed, so every run of a test sees the same delays.
+func (a *Allowed) DelayRandom(min, max time.Duration, seed int64) *Allowed {
+	if max <= min {
+		misuse("DelayRandom", "max delay (%s) must be greater than min delay (%s)", max, min)
+	}
+	var mutex sync.Mutex
+	r := rand.New(rand.NewSource(seed))
+	return a.delay("DelayRandom", func() time.Duration {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return min + time.Duration(r.Int63n(int64(max-min)))
+	})
+}
+
+// Hang causes the call to block until it is released, and then to behave as
+// programmed with Return, Panic or Do. A hanging call is released when:
+//   - the Mock is released (see Mock.Release and gomuti.Release),
+//   - the Mock is reset, e.g. when your test cleans up, or
+//   - a param of the call that is a context.Context is cancelled.
+//
+// Use it to test how your code copes with calls that never finish. A Do
+// function can check the context to tell whether the call was cancelled.
+func (a *Allowed) Hang() *Allowed {
+	l := a.mock.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	call := a.outcome("Hang")
+	if call.Hang || call.Delay != nil {
+		misuse("Hang", "cannot specify Hang() twice, or together with Delay()")
+	}
+	call.Hang = true
+	return a
+}
+
+func (a *Allowed) delay(verb string, d func() time.Duration) *Allowed {
+	l := a.mock.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	call := a.outcome(verb)
+	if call.Hang || call.Delay != nil {
+		misuse(verb, "cannot specify %s() twice, or together with Delay() or Hang()", verb)
+	}
+	call.Delay = d
+	return a
+}
+
+// Then begins a new behavior for the call, which takes effect after the
+// previous behavior has been used once. Follow it with Return, Panic or Do.
+// The final behavior in a sequence is repeated for every subsequent match.
+//
+// Example:
+//     Â(double, "Fetch").Return(page1).Then().Return(page2).Then().Panic("no more pages")
+func (a *Allowed) Then() *Allowed {
+	l := a.mock.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	call := a.current("Then")
+	prev := a.outcome("Then")
+	if prev.Do == nil && prev.Panic == nil && prev.Results == nil && prev.Delay == nil && !prev.Hang && prev.writes == nil {
+		misuse("Then", "must specify Return(), Panic(), Do(), Delay(), Hang() or SetArg() before Then()")
+	}
+	call.Then = append(call.Then, Call{})
+	return a
+}
+
+// ReturnInSequence specifies a sequence of single values that the mock should
+// return on successive matches; the final value is repeated for every
+// subsequent match. It is a shortcut for calling Return and Then repeatedly.
+// To sequence the results of a method that returns several values, use Then.
+func (a *Allowed) ReturnInSequence(results ...interface{}) *Allowed {
+	if len(results) == 0 {
+		misuse("ReturnInSequence", "must specify at least one result for ReturnInSequence()")
+	}
+	for i, r := range results {
+		if i > 0 {
+			a.Then()
+		}
+		a.Return(r)
+	}
+	return a
 }
 
 // ToReceive is an alias for Call()
@@ -220,22 +494,247 @@ func (a *Allowed) ToReceive(method string, params ...interface{}) *Allowed {
 }
 
 // AndReturn is an alias for Return()
-func (a *Allowed) AndReturn(results ...interface{}) {
-	a.Return(results...)
+func (a *Allowed) AndReturn(results ...interface{}) *Allowed {
+	return a.Return(results...)
 }
 
 // AndPanic is an alias for Panic()
-func (a *Allowed) AndPanic(reason interface{}) {
-	a.Panic(reason)
+func (a *Allowed) AndPanic(reason interface{}) *Allowed {
+	return a.Panic(reason)
+}
+
+// Required specifies that the call must be matched at least once. Required
+// calls that are never matched are reported by gomuti.Verify. It must be
+// called after Call/ToReceive.
+func (a *Allowed) Required() *Allowed {
+	l := a.mock.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	call := a.current("Required")
+	if !call.Required {
+		call.Required = true
+		call.MinTimes, call.MaxTimes = 1, -1
+	}
+	return a
+}
+
+// Times specifies that the call must be matched exactly the given number
+// of times; it implies Required. After it has been matched that many times,
+// the call is exhausted and Gomuti ignores it, falling through to the next
+// best match (if any). gomuti.Verify reports calls that were matched too few
+// times, or that were exhausted when no other call could match.
+//
+// It must be called after Call/ToReceive.
+func (a *Allowed) Times(number int) *Allowed {
+	l := a.mock.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	call := a.current("Times")
+	call.Required = true
+	call.MinTimes, call.MaxTimes = number, number
+	return a
+}
+
+// Never is a shortcut for Times(0); gomuti.Verify reports the call if it is
+// ever matched.
+func (a *Allowed) Never() *Allowed {
+	return a.Times(0)
+}
+
+// Once is a shortcut for Times(1).
+func (a *Allowed) Once() *Allowed {
+	return a.Times(1)
+}
+
+// Twice is a shortcut for Times(2).
+func (a *Allowed) Twice() *Allowed {
+	return a.Times(2)
+}
+
+// Returns the signature, without receiver, of the method of a type with the
+// given name; or nil if the type is unknown or has no such method. Considers
+// methods of both the type and a pointer to it.
+//
+// Methods of Mock and Spy are not the double's own, even if the double is (or
+// embeds) a Mock or Spy; for them, it also returns nil.
+func methodSignature(t reflect.Type, name string) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	if t.Kind() != reflect.Ptr {
+		t = reflect.PtrTo(t)
+	}
+	m, ok := t.MethodByName(name)
+	if !ok {
+		return nil
+	}
+	sig := withoutReceiver(m.Type)
+	for _, g := range gomutiTypes {
+		if gm, ok := g.MethodByName(name); ok && withoutReceiver(gm.Type) == sig {
+			return nil
+		}
+	}
+	return sig
+}
+
+// Types whose methods belong to Gomuti rather than to a test double.
+var gomutiTypes = []reflect.Type{reflect.TypeOf(&Mock{}), reflect.TypeOf(&Spy{})}
+
+// Returns the signature of a method, given the type of its method expression.
+func withoutReceiver(ft reflect.Type) reflect.Type {
+	in := make([]reflect.Type, ft.NumIn()-1)
+	for i := range in {
+		in[i] = ft.In(i + 1)
+	}
+	return reflect.FuncOf(in, outs(ft), ft.IsVariadic())
+}
+
+// Returns the types of a function's parameters.
+func ins(ft reflect.Type) []reflect.Type {
+	res := make([]reflect.Type, ft.NumIn())
+	for i := range res {
+		res[i] = ft.In(i)
+	}
+	return res
+}
+
+// Returns the types of a function's results.
+func outs(ft reflect.Type) []reflect.Type {
+	res := make([]reflect.Type, ft.NumOut())
+	for i := range res {
+		res[i] = ft.Out(i)
+	}
+	return res
+}
+
+// Panics with a SignatureError unless a function passed to Do can accept the
+// params of the method being mocked and return its results, allowing for the
+// conversions that Do makes (see convertible). CallFuncs are not checked.
+func (a *Allowed) checkDo(doer interface{}) {
+	if !a.checked(doer) {
+		return
+	}
+	ft := reflect.TypeOf(doer)
+
+	kind := "Do() parameter"
+	if ft.NumIn() != a.sig.NumIn() {
+		panic(&SignatureError{Method: a.last, Kind: kind, Index: -1, Want: a.sig.NumIn(), Got: ft.NumIn()})
+	}
+	for i := 0; i < ft.NumIn(); i++ {
+		if !convertible(a.sig.In(i), ft.In(i)) {
+			panic(&SignatureError{Method: a.last, Kind: kind, Index: i, Expected: a.sig.In(i), Actual: ft.In(i)})
+		}
+	}
+
+	kind = "Do() result"
+	if ft.NumOut() != a.sig.NumOut() {
+		panic(&SignatureError{Method: a.last, Kind: kind, Index: -1, Want: a.sig.NumOut(), Got: ft.NumOut()})
+	}
+	for i := 0; i < ft.NumOut(); i++ {
+		if !convertible(ft.Out(i), a.sig.Out(i)) {
+			panic(&SignatureError{Method: a.last, Kind: kind, Index: i, Expected: a.sig.Out(i), Actual: ft.Out(i)})
+		}
+	}
+}
+
+// Reports whether a function passed to Do is checked against the signature of
+// the method being mocked: only if the signature is known, and the function is
+// not a CallFunc.
+func (a *Allowed) checked(doer interface{}) bool {
+	if a.sig == nil {
+		return false
+	}
+	switch doer.(type) {
+	case CallFunc, func(...interface{}) []interface{}:
+		return false
+	}
+	ft := reflect.TypeOf(doer)
+	return ft != nil && ft.Kind() == reflect.Func
+}
+
+// Reports whether convertParam can convert some values of type from to type
+// to: values that are assignable; numbers of the same kind, if they fit; and
+// values of interface type whose dynamic type may be assignable.
+func convertible(from, to reflect.Type) bool {
+	switch {
+	case from.AssignableTo(to):
+		return true
+	case from.Kind() == reflect.Interface:
+		return to.Kind() == reflect.Interface || to.Implements(from)
+	}
+	k := numericKind(from.Kind())
+	return k != reflect.Invalid && k == numericKind(to.Kind())
+}
+
+// Converts the results of a function passed to Do to the result types of the
+// method being mocked, so that the test double can return them.
+func convertResults(sig, ft reflect.Type, df CallFunc) CallFunc {
+	return func(params ...interface{}) []interface{} {
+		res := df(params...)
+		for i, r := range res {
+			if r == nil || i >= sig.NumOut() {
+				continue
+			}
+			v, ok := convertParam(r, sig.Out(i))
+			if !ok {
+				panic(&BadDoSignatureError{Func: ft, Params: params,
+					Reason: fmt.Sprintf("result %d is %s, which cannot be returned as %s", i, typeName(r), sig.Out(i))})
+			}
+			res[i] = v.Interface()
+		}
+		return res
+	}
+}
+
+// Converts the values passed to Return to the result types of a method
+// signature, as convertParam does, or panics with a SignatureError.
+func convertReturns(method string, sig reflect.Type, results []interface{}) []interface{} {
+	want := outs(sig)
+	if len(results) != len(want) {
+		panic(&SignatureError{Method: method, Kind: "result", Index: -1, Want: len(want), Got: len(results)})
+	}
+	res := make([]interface{}, len(results))
+	for i, r := range results {
+		v, ok := convertParam(r, want[i])
+		if !ok {
+			panic(&SignatureError{Method: method, Kind: "result", Index: i, Expected: want[i], Actual: reflect.TypeOf(r)})
+		} else if r != nil {
+			res[i] = v.Interface()
+		}
+	}
+	return res
+}
+
+// Returns the call that is currently being programmed, or panics if Call()
+// has not been used yet. The caller must hold the lock.
+func (a *Allowed) current(verb string) *Call {
+	calls := a.mock[a.last]
+	if a.last == "" || len(calls) <= a.index {
+		misuse(verb, "must use Call() before specifying %s()", verb)
+	}
+	return &calls[a.index]
+}
+
+// Returns the call whose behavior is currently being programmed: the most
+// recent Then() of the current call, if any, or else the call itself. The
+// caller must hold the lock.
+func (a *Allowed) outcome(verb string) *Call {
+	call := a.current(verb)
+	if n := len(call.Then); n > 0 {
+		return &call.Then[n-1]
+	}
+	return call
 }
 
 // Ensure that the user only specifies ONE behavior: Do, Panic or Return.
-func (a Allowed) behave(d CallFunc, p interface{}, r []interface{}) {
+func (a Allowed) behave(verb string, d CallFunc, p interface{}, r []interface{}) {
 	if d != nil && p != nil {
-		panic("gomuti: cannot simultaneously Do() and Panic(); choose one")
+		misuse(verb, "cannot simultaneously Do() and Panic(); choose one")
 	} else if d != nil && r != nil {
-		panic("gomuti: cannot simultaneously Do() and Return(); choose one")
+		misuse(verb, "cannot simultaneously Do() and Return(); choose one")
 	} else if p != nil && r != nil {
-		panic("gomuti: cannot simultaneously Panic() and Return(); choose one")
+		misuse(verb, "cannot simultaneously Panic() and Return(); choose one")
 	}
 }
@@ -0,0 +1,149 @@
+package types
+
+import "reflect"
+
+// AllowedFunc is a type-safe layer over Allowed for mocking a method whose
+// signature is the function type F; use AllowFunc to create one. Do accepts
+// only functions of type F, and With and Return check their values against
+// F's parameters and results as soon as you call them, rather than when the
+// mocked method is called.
+//
+// Go's type parameters cannot express "the parameters of F", so With and
+// Return are not checked by the compiler; they panic with a SignatureError
+// instead. Literal params and results must have exactly the types that F
+// declares (e.g. int64(1), not 1); params may also be matchers, which are
+// not checked.
+//
+// For checks by the compiler, use one of the builders for a given shape of
+// method, such as AllowFunc2x1 for a method with two params and one result.
+//
+// AllowedFunc programs ordinary Calls, so you can mix it freely with the
+// untyped DSL; use Allowed to get at the untyped DSL for the same call.
+type AllowedFunc[F any] struct {
+	allowed *Allowed
+	method  string
+	fn      reflect.Type
+}
+
+// AllowFunc allows a Mock to receive a call to method, whose signature is the
+// function type F.
+//
+// Example:
+//     types.AllowFunc[func(int64, int64) int64](mock, "Add").With(int64(2), Anything()).Return(int64(4))
+func AllowFunc[F any](m Mock, method string) *AllowedFunc[F] {
+	var f F
+	ft := reflect.TypeOf(&f).Elem()
+	if ft.Kind() != reflect.Func {
+		misuse("AllowFunc", "type parameter of AllowFunc must be a function type; got %s", ft)
+	}
+	return &AllowedFunc[F]{allowed: m.Allow().Call(method), method: method, fn: ft}
+}
+
+// Allowed returns the untyped DSL object for the call being programmed.
+func (a *AllowedFunc[F]) Allowed() *Allowed {
+	return a.allowed
+}
+
+// With is like Allowed.With, but checks the params against F. A variadic
+// parameter is matched by a single slice or matcher, as with Allowed.With.
+func (a *AllowedFunc[F]) With(params ...interface{}) *AllowedFunc[F] {
+	checkSignature(a.method, "parameter", ins(a.fn), params, true)
+	a.allowed.With(params...)
+	return a
+}
+
+// Return is like Allowed.Return, but checks the results against F.
+func (a *AllowedFunc[F]) Return(results ...interface{}) *AllowedFunc[F] {
+	a.checkResults(results)
+	a.allowed.Return(results...)
+	return a
+}
+
+// ReturnInSequence is like Allowed.ReturnInSequence, but checks each result
+// against F, which must have exactly one result.
+func (a *AllowedFunc[F]) ReturnInSequence(results ...interface{}) *AllowedFunc[F] {
+	for _, r := range results {
+		a.checkResults([]interface{}{r})
+	}
+	a.allowed.ReturnInSequence(results...)
+	return a
+}
+
+// Do is like Allowed.Do, but accepts only a function of type F.
+func (a *AllowedFunc[F]) Do(fn F) *AllowedFunc[F] {
+	a.allowed.Do(fn)
+	return a
+}
+
+// Panic is the same as Allowed.Panic.
+func (a *AllowedFunc[F]) Panic(reason interface{}) *AllowedFunc[F] {
+	a.allowed.Panic(reason)
+	return a
+}
+
+// Then is the same as Allowed.Then.
+func (a *AllowedFunc[F]) Then() *AllowedFunc[F] {
+	a.allowed.Then()
+	return a
+}
+
+// Required is the same as Allowed.Required.
+func (a *AllowedFunc[F]) Required() *AllowedFunc[F] {
+	a.allowed.Required()
+	return a
+}
+
+// Times is the same as Allowed.Times.
+func (a *AllowedFunc[F]) Times(number int) *AllowedFunc[F] {
+	a.allowed.Times(number)
+	return a
+}
+
+// Never is the same as Allowed.Never.
+func (a *AllowedFunc[F]) Never() *AllowedFunc[F] {
+	return a.Times(0)
+}
+
+// Once is the same as Allowed.Once.
+func (a *AllowedFunc[F]) Once() *AllowedFunc[F] {
+	return a.Times(1)
+}
+
+// Twice is the same as Allowed.Twice.
+func (a *AllowedFunc[F]) Twice() *AllowedFunc[F] {
+	return a.Times(2)
+}
+
+func (a *AllowedFunc[F]) checkResults(results []interface{}) {
+	checkSignature(a.method, "result", outs(a.fn), results, false)
+}
+
+// Panics with a SignatureError unless each value can be used as the
+// corresponding type. If matchers is true, values that are Matchers are
+// accepted in any position.
+func checkSignature(method, kind string, want []reflect.Type, values []interface{}, matchers bool) {
+	if len(values) != len(want) {
+		panic(&SignatureError{Method: method, Kind: kind, Index: -1, Want: len(want), Got: len(values)})
+	}
+	for i, v := range values {
+		if _, ok := v.(Matcher); ok && matchers {
+			continue
+		}
+		if !fits(v, want[i]) {
+			panic(&SignatureError{Method: method, Kind: kind, Index: i, Expected: want[i], Actual: reflect.TypeOf(v)})
+		}
+	}
+}
+
+// Determine whether a value can be used as a given type.
+func fits(v interface{}, t reflect.Type) bool {
+	if v == nil {
+		switch t.Kind() {
+		case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+			return true
+		}
+		return false
+	}
+	return reflect.TypeOf(v).AssignableTo(t)
+}
+
@@ -0,0 +1,362 @@
+package types
+
+// Builders for methods of a given shape, whose params and results the
+// compiler checks: AllowFuncNxM allows a call to a method with N params and M
+// results, whose types are its type arguments. For instance, a method
+//
+//     Get(key string, n int) ([]byte, error)
+//
+// is mocked with
+//
+//     get := types.AllowFunc2x2[string, int, []byte, error](mock, "Get")
+//     get.With("a", 1).Return([]byte("b"), nil)
+//     get.Then().Return(nil, io.EOF)
+//
+// and get.With("a", "b") does not compile. With takes literal values only;
+// to match params with matchers, use the untyped With of Func. Generated test
+// doubles pass a variadic param as one slice, so declare it as a slice here.
+
+// The methods that all builders share; S is the type of the builder itself,
+// which they return, and F its function type.
+type funcChain[S, F any] struct {
+	allowed *AllowedFunc[F]
+	self    S
+}
+
+// Func returns the type-safe, but unchecked, DSL object for the call being
+// programmed.
+func (c *funcChain[S, F]) Func() *AllowedFunc[F] {
+	return c.allowed
+}
+
+// Allowed returns the untyped DSL object for the call being programmed.
+func (c *funcChain[S, F]) Allowed() *Allowed {
+	return c.allowed.Allowed()
+}
+
+// Do is the same as AllowedFunc.Do.
+func (c *funcChain[S, F]) Do(fn F) S {
+	c.allowed.Do(fn)
+	return c.self
+}
+
+// Panic is the same as Allowed.Panic.
+func (c *funcChain[S, F]) Panic(reason interface{}) S {
+	c.allowed.Panic(reason)
+	return c.self
+}
+
+// Then is the same as Allowed.Then.
+func (c *funcChain[S, F]) Then() S {
+	c.allowed.Then()
+	return c.self
+}
+
+// Required is the same as Allowed.Required.
+func (c *funcChain[S, F]) Required() S {
+	c.allowed.Required()
+	return c.self
+}
+
+// Times is the same as Allowed.Times.
+func (c *funcChain[S, F]) Times(number int) S {
+	c.allowed.Times(number)
+	return c.self
+}
+
+// Never is the same as Allowed.Never.
+func (c *funcChain[S, F]) Never() S {
+	return c.Times(0)
+}
+
+// Once is the same as Allowed.Once.
+func (c *funcChain[S, F]) Once() S {
+	return c.Times(1)
+}
+
+// Twice is the same as Allowed.Twice.
+func (c *funcChain[S, F]) Twice() S {
+	return c.Times(2)
+}
+
+// AllowedFunc0x0 allows a call to a method with no params and no results; see
+// AllowFunc0x0.
+type AllowedFunc0x0 struct {
+	funcChain[*AllowedFunc0x0, func()]
+}
+
+// AllowFunc0x0 allows a Mock to receive a call to a method of type func().
+func AllowFunc0x0(m Mock, method string) *AllowedFunc0x0 {
+	a := &AllowedFunc0x0{}
+	a.allowed, a.self = AllowFunc[func()](m, method), a
+	return a
+}
+
+// Return is like Allowed.Return, but takes no results.
+func (a *AllowedFunc0x0) Return() *AllowedFunc0x0 {
+	a.allowed.Return()
+	return a
+}
+
+// AllowedFunc0x1 allows a call to a method with no params and one result; see
+// AllowFunc0x1.
+type AllowedFunc0x1[R1 any] struct {
+	funcChain[*AllowedFunc0x1[R1], func() R1]
+}
+
+// AllowFunc0x1 allows a Mock to receive a call to a method of type func() R1.
+func AllowFunc0x1[R1 any](m Mock, method string) *AllowedFunc0x1[R1] {
+	a := &AllowedFunc0x1[R1]{}
+	a.allowed, a.self = AllowFunc[func() R1](m, method), a
+	return a
+}
+
+// Return is like Allowed.Return, but takes values of the result's type.
+func (a *AllowedFunc0x1[R1]) Return(r1 R1) *AllowedFunc0x1[R1] {
+	a.allowed.Return(r1)
+	return a
+}
+
+// AllowedFunc0x2 allows a call to a method with no params and two results; see
+// AllowFunc0x2.
+type AllowedFunc0x2[R1, R2 any] struct {
+	funcChain[*AllowedFunc0x2[R1, R2], func() (R1, R2)]
+}
+
+// AllowFunc0x2 allows a Mock to receive a call to a method of type func() (R1, R2).
+func AllowFunc0x2[R1, R2 any](m Mock, method string) *AllowedFunc0x2[R1, R2] {
+	a := &AllowedFunc0x2[R1, R2]{}
+	a.allowed, a.self = AllowFunc[func() (R1, R2)](m, method), a
+	return a
+}
+
+// Return is like Allowed.Return, but takes values of the results' types.
+func (a *AllowedFunc0x2[R1, R2]) Return(r1 R1, r2 R2) *AllowedFunc0x2[R1, R2] {
+	a.allowed.Return(r1, r2)
+	return a
+}
+
+// AllowedFunc1x0 allows a call to a method with one param and no results; see
+// AllowFunc1x0.
+type AllowedFunc1x0[P1 any] struct {
+	funcChain[*AllowedFunc1x0[P1], func(P1)]
+}
+
+// AllowFunc1x0 allows a Mock to receive a call to a method of type func(P1).
+func AllowFunc1x0[P1 any](m Mock, method string) *AllowedFunc1x0[P1] {
+	a := &AllowedFunc1x0[P1]{}
+	a.allowed, a.self = AllowFunc[func(P1)](m, method), a
+	return a
+}
+
+// With is like Allowed.With, but takes a literal value of the param's type.
+func (a *AllowedFunc1x0[P1]) With(p1 P1) *AllowedFunc1x0[P1] {
+	a.allowed.With(p1)
+	return a
+}
+
+// Return is like Allowed.Return, but takes no results.
+func (a *AllowedFunc1x0[P1]) Return() *AllowedFunc1x0[P1] {
+	a.allowed.Return()
+	return a
+}
+
+// AllowedFunc1x1 allows a call to a method with one param and one result; see
+// AllowFunc1x1.
+type AllowedFunc1x1[P1, R1 any] struct {
+	funcChain[*AllowedFunc1x1[P1, R1], func(P1) R1]
+}
+
+// AllowFunc1x1 allows a Mock to receive a call to a method of type func(P1) R1.
+func AllowFunc1x1[P1, R1 any](m Mock, method string) *AllowedFunc1x1[P1, R1] {
+	a := &AllowedFunc1x1[P1, R1]{}
+	a.allowed, a.self = AllowFunc[func(P1) R1](m, method), a
+	return a
+}
+
+// With is like Allowed.With, but takes a literal value of the param's type.
+func (a *AllowedFunc1x1[P1, R1]) With(p1 P1) *AllowedFunc1x1[P1, R1] {
+	a.allowed.With(p1)
+	return a
+}
+
+// Return is like Allowed.Return, but takes values of the result's type.
+func (a *AllowedFunc1x1[P1, R1]) Return(r1 R1) *AllowedFunc1x1[P1, R1] {
+	a.allowed.Return(r1)
+	return a
+}
+
+// AllowedFunc1x2 allows a call to a method with one param and two results; see
+// AllowFunc1x2.
+type AllowedFunc1x2[P1, R1, R2 any] struct {
+	funcChain[*AllowedFunc1x2[P1, R1, R2], func(P1) (R1, R2)]
+}
+
+// AllowFunc1x2 allows a Mock to receive a call to a method of type func(P1) (R1, R2).
+func AllowFunc1x2[P1, R1, R2 any](m Mock, method string) *AllowedFunc1x2[P1, R1, R2] {
+	a := &AllowedFunc1x2[P1, R1, R2]{}
+	a.allowed, a.self = AllowFunc[func(P1) (R1, R2)](m, method), a
+	return a
+}
+
+// With is like Allowed.With, but takes a literal value of the param's type.
+func (a *AllowedFunc1x2[P1, R1, R2]) With(p1 P1) *AllowedFunc1x2[P1, R1, R2] {
+	a.allowed.With(p1)
+	return a
+}
+
+// Return is like Allowed.Return, but takes values of the results' types.
+func (a *AllowedFunc1x2[P1, R1, R2]) Return(r1 R1, r2 R2) *AllowedFunc1x2[P1, R1, R2] {
+	a.allowed.Return(r1, r2)
+	return a
+}
+
+// AllowedFunc2x0 allows a call to a method with two params and no results; see
+// AllowFunc2x0.
+type AllowedFunc2x0[P1, P2 any] struct {
+	funcChain[*AllowedFunc2x0[P1, P2], func(P1, P2)]
+}
+
+// AllowFunc2x0 allows a Mock to receive a call to a method of type func(P1, P2).
+func AllowFunc2x0[P1, P2 any](m Mock, method string) *AllowedFunc2x0[P1, P2] {
+	a := &AllowedFunc2x0[P1, P2]{}
+	a.allowed, a.self = AllowFunc[func(P1, P2)](m, method), a
+	return a
+}
+
+// With is like Allowed.With, but takes literal values of the params' types.
+func (a *AllowedFunc2x0[P1, P2]) With(p1 P1, p2 P2) *AllowedFunc2x0[P1, P2] {
+	a.allowed.With(p1, p2)
+	return a
+}
+
+// Return is like Allowed.Return, but takes no results.
+func (a *AllowedFunc2x0[P1, P2]) Return() *AllowedFunc2x0[P1, P2] {
+	a.allowed.Return()
+	return a
+}
+
+// AllowedFunc2x1 allows a call to a method with two params and one result; see
+// AllowFunc2x1.
+type AllowedFunc2x1[P1, P2, R1 any] struct {
+	funcChain[*AllowedFunc2x1[P1, P2, R1], func(P1, P2) R1]
+}
+
+// AllowFunc2x1 allows a Mock to receive a call to a method of type func(P1, P2) R1.
+func AllowFunc2x1[P1, P2, R1 any](m Mock, method string) *AllowedFunc2x1[P1, P2, R1] {
+	a := &AllowedFunc2x1[P1, P2, R1]{}
+	a.allowed, a.self = AllowFunc[func(P1, P2) R1](m, method), a
+	return a
+}
+
+// With is like Allowed.With, but takes literal values of the params' types.
+func (a *AllowedFunc2x1[P1, P2, R1]) With(p1 P1, p2 P2) *AllowedFunc2x1[P1, P2, R1] {
+	a.allowed.With(p1, p2)
+	return a
+}
+
+// Return is like Allowed.Return, but takes values of the result's type.
+func (a *AllowedFunc2x1[P1, P2, R1]) Return(r1 R1) *AllowedFunc2x1[P1, P2, R1] {
+	a.allowed.Return(r1)
+	return a
+}
+
+// AllowedFunc2x2 allows a call to a method with two params and two results; see
+// AllowFunc2x2.
+type AllowedFunc2x2[P1, P2, R1, R2 any] struct {
+	funcChain[*AllowedFunc2x2[P1, P2, R1, R2], func(P1, P2) (R1, R2)]
+}
+
+// AllowFunc2x2 allows a Mock to receive a call to a method of type func(P1, P2) (R1, R2).
+func AllowFunc2x2[P1, P2, R1, R2 any](m Mock, method string) *AllowedFunc2x2[P1, P2, R1, R2] {
+	a := &AllowedFunc2x2[P1, P2, R1, R2]{}
+	a.allowed, a.self = AllowFunc[func(P1, P2) (R1, R2)](m, method), a
+	return a
+}
+
+// With is like Allowed.With, but takes literal values of the params' types.
+func (a *AllowedFunc2x2[P1, P2, R1, R2]) With(p1 P1, p2 P2) *AllowedFunc2x2[P1, P2, R1, R2] {
+	a.allowed.With(p1, p2)
+	return a
+}
+
+// Return is like Allowed.Return, but takes values of the results' types.
+func (a *AllowedFunc2x2[P1, P2, R1, R2]) Return(r1 R1, r2 R2) *AllowedFunc2x2[P1, P2, R1, R2] {
+	a.allowed.Return(r1, r2)
+	return a
+}
+
+// AllowedFunc3x0 allows a call to a method with three params and no results; see
+// AllowFunc3x0.
+type AllowedFunc3x0[P1, P2, P3 any] struct {
+	funcChain[*AllowedFunc3x0[P1, P2, P3], func(P1, P2, P3)]
+}
+
+// AllowFunc3x0 allows a Mock to receive a call to a method of type func(P1, P2, P3).
+func AllowFunc3x0[P1, P2, P3 any](m Mock, method string) *AllowedFunc3x0[P1, P2, P3] {
+	a := &AllowedFunc3x0[P1, P2, P3]{}
+	a.allowed, a.self = AllowFunc[func(P1, P2, P3)](m, method), a
+	return a
+}
+
+// With is like Allowed.With, but takes literal values of the params' types.
+func (a *AllowedFunc3x0[P1, P2, P3]) With(p1 P1, p2 P2, p3 P3) *AllowedFunc3x0[P1, P2, P3] {
+	a.allowed.With(p1, p2, p3)
+	return a
+}
+
+// Return is like Allowed.Return, but takes no results.
+func (a *AllowedFunc3x0[P1, P2, P3]) Return() *AllowedFunc3x0[P1, P2, P3] {
+	a.allowed.Return()
+	return a
+}
+
+// AllowedFunc3x1 allows a call to a method with three params and one result; see
+// AllowFunc3x1.
+type AllowedFunc3x1[P1, P2, P3, R1 any] struct {
+	funcChain[*AllowedFunc3x1[P1, P2, P3, R1], func(P1, P2, P3) R1]
+}
+
+// AllowFunc3x1 allows a Mock to receive a call to a method of type func(P1, P2, P3) R1.
+func AllowFunc3x1[P1, P2, P3, R1 any](m Mock, method string) *AllowedFunc3x1[P1, P2, P3, R1] {
+	a := &AllowedFunc3x1[P1, P2, P3, R1]{}
+	a.allowed, a.self = AllowFunc[func(P1, P2, P3) R1](m, method), a
+	return a
+}
+
+// With is like Allowed.With, but takes literal values of the params' types.
+func (a *AllowedFunc3x1[P1, P2, P3, R1]) With(p1 P1, p2 P2, p3 P3) *AllowedFunc3x1[P1, P2, P3, R1] {
+	a.allowed.With(p1, p2, p3)
+	return a
+}
+
+// Return is like Allowed.Return, but takes values of the result's type.
+func (a *AllowedFunc3x1[P1, P2, P3, R1]) Return(r1 R1) *AllowedFunc3x1[P1, P2, P3, R1] {
+	a.allowed.Return(r1)
+	return a
+}
+
+// AllowedFunc3x2 allows a call to a method with three params and two results; see
+// AllowFunc3x2.
+type AllowedFunc3x2[P1, P2, P3, R1, R2 any] struct {
+	funcChain[*AllowedFunc3x2[P1, P2, P3, R1, R2], func(P1, P2, P3) (R1, R2)]
+}
+
+// AllowFunc3x2 allows a Mock to receive a call to a method of type func(P1, P2, P3) (R1, R2).
+func AllowFunc3x2[P1, P2, P3, R1, R2 any](m Mock, method string) *AllowedFunc3x2[P1, P2, P3, R1, R2] {
+	a := &AllowedFunc3x2[P1, P2, P3, R1, R2]{}
+	a.allowed, a.self = AllowFunc[func(P1, P2, P3) (R1, R2)](m, method), a
+	return a
+}
+
+// With is like Allowed.With, but takes literal values of the params' types.
+func (a *AllowedFunc3x2[P1, P2, P3, R1, R2]) With(p1 P1, p2 P2, p3 P3) *AllowedFunc3x2[P1, P2, P3, R1, R2] {
+	a.allowed.With(p1, p2, p3)
+	return a
+}
+
+// Return is like Allowed.Return, but takes values of the results' types.
+func (a *AllowedFunc3x2[P1, P2, P3, R1, R2]) Return(r1 R1, r2 R2) *AllowedFunc3x2[P1, P2, P3, R1, R2] {
+	a.allowed.Return(r1, r2)
+	return a
+}
@@ -0,0 +1,122 @@
+package types_test
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"io"
+	"reflect"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/xeger/gomuti"
+	"github.com/xeger/gomuti/types"
+)
+
+var _ = Describe("AllowedFunc", func() {
+	type add = func(int64, int64) int64
+	type read = func([]byte) (int, error)
+
+	var m types.Mock
+
+	BeforeEach(func() {
+		m = types.Mock{}
+	})
+
+	It("programs ordinary calls", func() {
+		types.AllowFunc[add](m, "Add").With(int64(1), Anything()).Return(int64(42))
+		m.Allow().Call("Add").With(int64(2), int64(2)).Return(int64(4))
+
+		Expect(m.Call("Add", int64(1), int64(7))).To(Equal([]interface{}{int64(42)}))
+		Expect(m.Call("Add", int64(2), int64(2))).To(Equal([]interface{}{int64(4)}))
+	})
+
+	It("accepts functions of the right type", func() {
+		types.AllowFunc[add](m, "Add").Do(func(l, r int64) int64 { return l * r })
+		Expect(m.Call("Add", int64(3), int64(4))).To(Equal([]interface{}{int64(12)}))
+	})
+
+	It("accepts nil for nillable types", func() {
+		types.AllowFunc[read](m, "Read").With(nil).Return(0, io.EOF).Then().Return(1, nil)
+	})
+
+	It("supports sequences and counts", func() {
+		types.AllowFunc[add](m, "Add").ReturnInSequence(int64(1), int64(2)).Twice()
+		Expect(m.Call("Add", int64(0), int64(0))).To(Equal([]interface{}{int64(1)}))
+		Expect(m.Call("Add", int64(0), int64(0))).To(Equal([]interface{}{int64(2)}))
+		Expect(m.Call("Add", int64(0), int64(0))).To(BeNil())
+	})
+
+	It("rejects params that do not fit", func() {
+		Expect(func() {
+			types.AllowFunc[add](m, "Add").With(1, 2)
+		}).To(PanicWith(&types.SignatureError{
+			Method: "Add", Kind: "parameter", Index: 0, Expected: reflect.TypeOf(int64(0)), Actual: reflect.TypeOf(0),
+		}))
+		Expect(func() {
+			types.AllowFunc[add](m, "Add").With(int64(1))
+		}).To(PanicWith(MatchError("gomuti: Add: expected 2 parameters; got 1")))
+	})
+
+	It("rejects results that do not fit", func() {
+		Expect(func() {
+			types.AllowFunc[read](m, "Read").Return(0, "oops")
+		}).To(PanicWith(MatchError("gomuti: Read: result 1 should be error, not string")))
+		Expect(func() {
+			types.AllowFunc[read](m, "Read").Return(nil, nil)
+		}).To(PanicWith(MatchError("gomuti: Read: result 0 should be int, not nil")))
+		Expect(func() {
+			types.AllowFunc[add](m, "Add").ReturnInSequence(int64(1), 2)
+		}).To(PanicWith(BeAssignableToTypeOf(&types.SignatureError{})))
+	})
+
+	It("has builders that the compiler checks", func() {
+		types.AllowFunc2x1[int64, int64, int64](m, "Add").With(1, 2).Return(3).Then().Return(4)
+		Expect(m.Call("Add", int64(1), int64(2))).To(Equal([]interface{}{int64(3)}))
+		Expect(m.Call("Add", int64(1), int64(2))).To(Equal([]interface{}{int64(4)}))
+
+		types.AllowFunc1x2[[]byte, int, error](m, "Read").With(nil).Return(0, io.EOF).Once()
+		Expect(m.Call("Read", []byte(nil))).To(Equal([]interface{}{0, io.EOF}))
+		Expect(m.Call("Read", []byte(nil))).To(BeNil())
+
+		types.AllowFunc0x0(m, "Close").Return()
+		types.AllowFunc3x0[string, interface{}, bool](m, "Log").With("x", 1, true).Return()
+		types.AllowFunc0x1[string](m, "Name").Do(func() string { return "n" })
+		Expect(m.Call("Close")).To(BeEmpty())
+		Expect(m.Call("Log", "x", 1, true)).To(BeEmpty())
+		Expect(m.Call("Name")).To(Equal([]interface{}{"n"}))
+	})
+
+	It("has builders that do not compile given values of the wrong type", func() {
+		check := func(body string) error {
+			src := "package p\n\nimport \"github.com/xeger/gomuti/types\"\n\nfunc f(m types.Mock) {\n\t" + body + "\n}\n"
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, "p.go", src, 0)
+			Expect(err).NotTo(HaveOccurred())
+			conf := gotypes.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+			_, err = conf.Check("p", fset, []*ast.File{f}, nil)
+			return err
+		}
+
+		Expect(check(`types.AllowFunc2x1[int64, int64, int64](m, "Add").With(1, 2).Return(3)`)).To(Succeed())
+		Expect(check(`types.AllowFunc2x1[int64, int64, int64](m, "Add").With(1, "2")`)).To(MatchError(ContainSubstring(`cannot use "2"`)))
+		Expect(check(`types.AllowFunc2x1[int64, int64, int64](m, "Add").With(1)`)).To(MatchError(ContainSubstring("not enough arguments")))
+		Expect(check(`types.AllowFunc1x2[[]byte, int, error](m, "Read").Return(0, "oops")`)).To(MatchError(ContainSubstring(`cannot use "oops"`)))
+		Expect(check(`types.AllowFunc0x1[int](m, "Count").Do(func() int64 { return 0 })`)).To(MatchError(ContainSubstring("value of type func() int64")))
+	})
+
+	It("rejects type parameters that are not functions", func() {
+		Expect(func() {
+			types.AllowFunc[int](m, "Add")
+		}).To(PanicWith(BeAssignableToTypeOf(&types.DSLMisuseError{})))
+	})
+
+	It("works with test doubles", func() {
+		double := &hammered{}
+		AllowFunc[func(int, int) int](double, "Add").With(1, 2).Return(3)
+		Expect(double.Add(1, 2)).To(Equal(3))
+		Expect(AllowFunc[add](double, "Add").Allowed()).NotTo(BeNil())
+	})
+})
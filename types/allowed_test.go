@@ -1,6 +1,8 @@
 package types_test
 
 import (
+	"errors"
+	"fmt"
 	"net/url"
 	"reflect"
 
@@ -55,6 +57,15 @@ var _ = Describe("Allowed", func() {
 				a.Return(false)
 			}).To(Panic())
 		})
+
+		It("panics with DSLMisuseError", func() {
+			Expect(func() {
+				Â(Receiver).Call("Foo").With(1).With(2)
+			}).To(PanicWith(&types.DSLMisuseError{Method: "With", Reason: "cannot specify With() twice"}))
+			Expect(func() {
+				Â(Receiver).Return(false)
+			}).To(PanicWith(BeAssignableToTypeOf(&types.DSLMisuseError{})))
+		})
 	})
 
 	Context("With", func() {
@@ -151,5 +162,221 @@ var _ = Describe("Allowed", func() {
 				Receiver.Call("Foo", 174)
 			}).To(Panic())
 		})
+
+		It("describes parameter mismatches with BadDoSignatureError", func() {
+			Â(Receiver).Call("Foo").Do(func(likable bool) bool {
+				return likable
+			})
+
+			defer func() {
+				var bad *types.BadDoSignatureError
+				Expect(errors.As(recover().(error), &bad)).To(BeTrue())
+				Expect(bad.Func).To(Equal(reflect.TypeOf(func(bool) bool { return false })))
+				Expect(bad.Params).To(Equal([]interface{}{174}))
+				Expect(bad.Error()).To(ContainSubstring("parameter 0 is int"))
+			}()
+			Receiver.Call("Foo", 174)
+		})
+
+		It("passes nil as a typed zero value", func() {
+			Â(Receiver).Call("Foo").Do(func(err error, p *int) bool {
+				return err == nil && p == nil
+			})
+			Expect(Receiver.Call("Foo", nil, nil)).To(Equal([]interface{}{true}))
+		})
+
+		It("converts numbers that fit", func() {
+			Â(Receiver).Call("Foo").Do(func(i int32, u uint8, f float32) float64 {
+				return float64(i) + float64(u) + float64(f)
+			})
+			Expect(Receiver.Call("Foo", int64(1), uint64(2), 0.5)).To(Equal([]interface{}{3.5}))
+
+			Expect(func() {
+				Receiver.Call("Foo", int64(1)<<40, uint64(2), 0.5)
+			}).To(PanicWith(BeAssignableToTypeOf(&types.BadDoSignatureError{})))
+			Expect(func() {
+				Receiver.Call("Foo", 1, -2, 0.5)
+			}).To(PanicWith(BeAssignableToTypeOf(&types.BadDoSignatureError{})))
+		})
+
+		Context("given a variadic function", func() {
+			var printf func(format string, args ...interface{}) string
+
+			BeforeEach(func() {
+				printf = func(format string, args ...interface{}) string {
+					return fmt.Sprintf(format, args...)
+				}
+				Â(Receiver).Call("Printf").Do(printf)
+			})
+
+			It("accepts variadic params as a slice", func() {
+				Expect(Receiver.Call("Printf", "%d-%s", []interface{}{1, "a"})).To(Equal([]interface{}{"1-a"}))
+				Expect(Receiver.Call("Printf", "none", []interface{}(nil))).To(Equal([]interface{}{"none"}))
+				Expect(Receiver.Call("Printf", "none", nil)).To(Equal([]interface{}{"none"}))
+			})
+
+			It("accepts variadic params one by one", func() {
+				Expect(Receiver.Call("Printf", "%d-%s", 1, "a")).To(Equal([]interface{}{"1-a"}))
+				Expect(Receiver.Call("Printf", "none")).To(Equal([]interface{}{"none"}))
+			})
+
+			It("converts variadic numbers", func() {
+				Â(Receiver).Call("Sum").Do(func(ns ...int) int {
+					total := 0
+					for _, n := range ns {
+						total += n
+					}
+					return total
+				})
+				Expect(Receiver.Call("Sum", []int{1, 2, 3})).To(Equal([]interface{}{6}))
+				Expect(Receiver.Call("Sum", int64(1), int8(2))).To(Equal([]interface{}{3}))
+			})
+		})
+	})
+
+	Context("given the type of a test double", func() {
+		var double *hammered
+
+		BeforeEach(func() {
+			double = &hammered{Mock: Receiver}
+		})
+
+		It("accepts results and functions that fit the method", func() {
+			Receiver.AllowFor(double).Call("Add").With(1, 2).Return(3)
+			Receiver.AllowFor(double).Call("Add").With(2, 2).Do(func(l, r int) int { return l * r })
+			Receiver.AllowFor(double).Call("Add").With(3, 3).Do(func(params ...interface{}) []interface{} {
+				return []interface{}{0}
+			})
+			Expect(Receiver.Call("Add", 1, 2)).To(Equal([]interface{}{3}))
+			Expect(Receiver.Call("Add", 2, 2)).To(Equal([]interface{}{4}))
+			Expect(Receiver.Call("Add", 3, 3)).To(Equal([]interface{}{0}))
+		})
+
+		It("rejects results that do not fit the method", func() {
+			Expect(func() {
+				Receiver.AllowFor(double).Call("Add").Return("three")
+			}).To(PanicWith(MatchError("gomuti: Add: result 0 should be int, not string")))
+			Expect(func() {
+				Receiver.AllowFor(double).Call("Add").Return(1, 2)
+			}).To(PanicWith(MatchError("gomuti: Add: expected 1 result; got 2")))
+		})
+
+		It("rejects functions that do not fit the method", func() {
+			Expect(func() {
+				Receiver.AllowFor(double).Call("Add").Do(func(l int) int { return l })
+			}).To(PanicWith(MatchError("gomuti: Add: expected 2 Do() parameters; got 1")))
+			Expect(func() {
+				Receiver.AllowFor(double).Call("Add").Do(func(l, r string) int { return 0 })
+			}).To(PanicWith(MatchError("gomuti: Add: Do() parameter 0 should be int, not string")))
+			Expect(func() {
+				Receiver.AllowFor(double).Call("Add").Do(func(l, r int) {})
+			}).To(PanicWith(MatchError("gomuti: Add: expected 1 Do() result; got 0")))
+			Expect(func() {
+				Allow(double).Call("Add").Do(func(l, r int) string { return "" })
+			}).To(PanicWith(BeAssignableToTypeOf(&types.SignatureError{})))
+			Expect(func() {
+				Allow(double).Call("Add").Do(func(l, r uint) int { return 0 })
+			}).To(PanicWith(MatchError("gomuti: Add: Do() parameter 0 should be int, not uint")))
+		})
+
+		It("accepts functions whose numbers convert", func() {
+			Receiver.AllowFor(double).Call("Add").With(1, Anything()).Do(func(l, r int64) int64 { return l + r })
+			Allow(double).Call("Add").With(2, Anything()).Do(func(l, r int32) int8 { return int8(l * r) })
+			Expect(double.Add(1, 2)).To(Equal(3))
+			Expect(double.Add(2, 3)).To(Equal(6))
+			Expect(func() {
+				double.Add(2, 1<<40)
+			}).To(PanicWith(BeAssignableToTypeOf(&types.BadDoSignatureError{})))
+		})
+
+		It("converts results whose numbers fit", func() {
+			Allow(double).Call("Add").With(1, Anything()).Return(int64(3))
+			Allow(double).Call("Add").With(2, Anything()).Return(int8(4))
+			Expect(double.Add(1, 2)).To(Equal(3))
+			Expect(double.Add(2, 2)).To(Equal(4))
+			Expect(func() {
+				Allow(double).Call("Add").Return(2.5)
+			}).To(PanicWith(MatchError("gomuti: Add: result 0 should be int, not float64")))
+			Expect(func() {
+				Allow(double).Call("Add").Return(uint8(1))
+			}).To(PanicWith(BeAssignableToTypeOf(&types.SignatureError{})))
+		})
+
+		It("does not mistake the methods of a Mock for those of the double", func() {
+			for _, name := range []string{"Call", "Reset", "Release", "Explain", "Allow", "Unsatisfied"} {
+				Allow(types.Mock{}).Call(name).Return(42)
+				Allow(&types.Mock{}).Call(name).Return(42)
+				Allow(double).Call(name).Return(42)
+			}
+		})
+
+		It("does not check methods that the double lacks", func() {
+			Receiver.AllowFor(double).Call("Subtract").Return("anything", "at all")
+			Expect(Receiver.Call("Subtract")).To(HaveLen(2))
+		})
+	})
+
+	Context("Times", func() {
+		It("exhausts the call and falls through", func() {
+			Â(Receiver).Call("Fetch").Return("fallback")
+			Â(Receiver).Call("Fetch").Twice().Return("limited")
+
+			Expect(Receiver.Call("Fetch")).To(Equal([]interface{}{"limited"}))
+			Expect(Receiver.Call("Fetch")).To(Equal([]interface{}{"limited"}))
+			Expect(Receiver.Call("Fetch")).To(Equal([]interface{}{"fallback"}))
+		})
+
+		It("stops matching when there is no fallback", func() {
+			Â(Receiver).Call("Fetch").Once().Return(1)
+			Expect(Receiver.Call("Fetch")).NotTo(BeNil())
+			Expect(Receiver.Call("Fetch")).To(BeNil())
+			Expect(Receiver.Unsatisfied()["Fetch"]).To(HaveLen(1))
+		})
+
+		It("prefers a less specific call over an exhausted one", func() {
+			Â(Receiver).Call("Fetch").With(Anything()).Return("any")
+			Â(Receiver).Call("Fetch").With(1).Once().Return("one")
+			Expect(Receiver.Call("Fetch", 1)).To(Equal([]interface{}{"one"}))
+			Expect(Receiver.Call("Fetch", 1)).To(Equal([]interface{}{"any"}))
+			Expect(Receiver.Unsatisfied()).To(BeEmpty())
+		})
+	})
+
+	Context("Then", func() {
+		It("sequences behaviors", func() {
+			Â(Receiver).Call("Next").Return(1).Then().Return(2).Then().Panic("no more")
+			Expect(Receiver.Call("Next")).To(Equal([]interface{}{1}))
+			Expect(Receiver.Call("Next")).To(Equal([]interface{}{2}))
+			Expect(func() { Receiver.Call("Next") }).To(Panic())
+			Expect(func() { Receiver.Call("Next") }).To(Panic())
+		})
+
+		It("combines with Times", func() {
+			Â(Receiver).Call("Next").Return("done")
+			Â(Receiver).Call("Next").Times(2).Return(1, nil).Then().Return(2, nil)
+			Expect(Receiver.Call("Next")).To(Equal([]interface{}{1, nil}))
+			Expect(Receiver.Call("Next")).To(Equal([]interface{}{2, nil}))
+			Expect(Receiver.Call("Next")).To(Equal([]interface{}{"done"}))
+		})
+
+		It("panics without a prior behavior", func() {
+			Expect(func() {
+				Â(Receiver).Call("Next").Then()
+			}).To(Panic())
+			Expect(func() {
+				Â(Receiver).Call("Next").Return(1).Then().Then()
+			}).To(Panic())
+		})
+	})
+
+	Context("ReturnInSequence", func() {
+		It("returns each value once, then repeats the last", func() {
+			Â(Receiver).Call("Next").ReturnInSequence("a", "b", "c")
+			Expect(Receiver.Call("Next")).To(Equal([]interface{}{"a"}))
+			Expect(Receiver.Call("Next")).To(Equal([]interface{}{"b"}))
+			Expect(Receiver.Call("Next")).To(Equal([]interface{}{"c"}))
+			Expect(Receiver.Call("Next")).To(Equal([]interface{}{"c"}))
+		})
 	})
 })
+
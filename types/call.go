@@ -1,42 +1,243 @@
 package types
 
-import "github.com/onsi/gomega/matchers"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
 
 // Call represents a method call that has been programmed on a Mock with
 // a call to `gomuti.Allow()`.
+//
+// The first time a call is matched, it behaves according to its Do, Panic or
+// Results; subsequent matches use the behaviors in Then, in order, and the
+// final behavior is repeated indefinitely. The Params of each Call in Then are
+// not used.
+//
+// Before behaving, a call waits for the duration returned by its Delay, if
+// any, or until it is released if it should Hang; see Allowed.Delay and
+// Allowed.Hang. Then it writes through the params of the method call as
+// programmed with Allowed.SetArg and Allowed.CopyInto.
+//
+// Required calls are reported by Mock.Unsatisfied (and therefore by
+// gomuti.Verify) unless they have been matched at least MinTimes and at most
+// MaxTimes; a negative MaxTimes means there is no upper bound. A required call
+// is exhausted once it has been used MaxTimes times, and will not be matched
+// again.
 type Call struct {
 	Params  []Matcher
 	Do      CallFunc
 	Panic   interface{}
 	Results []interface{}
+	Delay   func() time.Duration
+	Hang    bool
+	Then    []Call
+
+	Required           bool
+	MinTimes, MaxTimes int
+
+	stats *callStats
+	// values to write through params; see Allowed.SetArg
+	writes []argWrite
+}
+
+// Mutable statistics about a Call; shared by all copies of the Call. They
+// are only updated while the lock of the Call's Mock is held, but may be read
+// without it.
+type callStats struct {
+	// number of times the call's behavior has been used
+	used atomic.Int64
+	// number of times the call was the best match, but exhausted
+	excess atomic.Int64
+}
+
+// Matched returns the number of times this call has been the best match for
+// a method call, including any times when it had already been exhausted.
+// Copies of a Call share the same count.
+func (c *Call) Matched() int {
+	return c.matched()
+}
+
+func (c *Call) matched() int {
+	if c.stats == nil {
+		return 0
+	}
+	return int(c.stats.used.Load() + c.stats.excess.Load())
+}
+
+// Returns the number of times this call's behavior has been used.
+func (c *Call) used() int {
+	if c.stats == nil {
+		return 0
+	}
+	return int(c.stats.used.Load())
+}
+
+// Determine whether this call has been used as many times as it may be.
+func (c *Call) exhausted() bool {
+	return c.Required && c.MaxTimes >= 0 && c.used() >= c.MaxTimes
 }
 
-// Determine whether this call's Params match the given params, and if so,
-// how well. Returns 0 without match, positive integer with match.
-func (c *Call) score(params []interface{}) int {
+// Record a use of this call and return the Call (either this one or an element
+// of its Then) whose behavior should be used. The caller must hold the lock.
+func (c *Call) use() *Call {
+	if c.stats == nil {
+		return c
+	}
+	n := int(c.stats.used.Add(1)) - 1
+	if n == 0 || len(c.Then) == 0 {
+		return c
+	} else if n > len(c.Then) {
+		n = len(c.Then)
+	}
+	return &c.Then[n-1]
+}
+
+// Record params with any captors among this call's Params. The caller must
+// hold the lock.
+func (c *Call) capture(params []interface{}) {
+	for i, m := range c.Params {
+		if cap, ok := m.(capturer); ok && i < len(params) {
+			cap.capture(params[i])
+		}
+	}
+}
+
+// Determine whether a required call has been matched too few or too many
+// times.
+func (c *Call) unsatisfied() bool {
+	if !c.Required {
+		return false
+	}
+	n := c.matched()
+	return n < c.MinTimes || (c.MaxTimes >= 0 && n > c.MaxTimes)
+}
+
+// Determine whether this call's Params match the given params of a call to
+// method, and if so, how well. Returns 0 without match, positive integer with
+// match. Panics with a MatcherError if a matcher fails.
+func (c *Call) score(method string, params []interface{}) int {
 	if c.Params == nil {
 		// a Call with no Params matches any parameters, but just barely...
 		return 1
-	} else if len(c.Params) == len(params) {
+	}
+	params = gatherRest(c.Params, params)
+	if len(c.Params) == len(params) {
 		// compute the score by considering all matchy matchers
 		score := 0
 		for i, p := range params {
-			success, err := c.Params[i].Match(widen(p))
+			success, err := matchParam(c.Params[i], p)
 			if err != nil {
-				panic(err.Error())
+				panic(&MatcherError{Method: method, Index: i, Err: err})
 			} else if !success {
 				return 0
 			}
-			switch c.Params[i].(type) {
-			case *matchers.EqualMatcher, *matchers.BeNilMatcher:
-				score += 4
-			case *matchers.BeEquivalentToMatcher:
-				score += 3
-			default:
-				score += 2
-			}
+			score += weight(c.Params[i])
 		}
 		return score
 	}
 	return 0
 }
+
+// Returns the score contributed by a matcher that accepted a parameter; more
+// specific matchers score higher. See WeightedMatcher.
+func weight(m Matcher) int {
+	wm, ok := m.(WeightedMatcher)
+	if !ok {
+		return foreignWeight(m)
+	} else if w := wm.Weight(); w > 1 {
+		return w
+	}
+	return 1
+}
+
+// Weights of Gomega's equality matchers, which score as Gomuti's own do, by
+// package path and type name; Gomuti does not import Gomega, so that test
+// doubles need not either.
+var gomegaWeights = map[string]int{
+	"EqualMatcher":          4,
+	"BeNilMatcher":          4,
+	"BeEquivalentToMatcher": 3,
+}
+
+// Import path of Gomega's matchers.
+const gomegaMatchers = "github.com/onsi/gomega/matchers"
+
+// Returns the weight of a matcher that is not a WeightedMatcher: that of
+// Gomega's equality matchers, or else 2.
+func foreignWeight(m Matcher) int {
+	if t := reflect.TypeOf(m); t.Kind() == reflect.Ptr && t.Elem().PkgPath() == gomegaMatchers {
+		if w, ok := gomegaWeights[t.Elem().Name()]; ok {
+			return w
+		}
+	}
+	return 2
+}
+
+// Explain how well this call's Params match the given params. Unlike score,
+// it does not panic if a matcher fails, but explains the failure. The caller
+// must hold the lock.
+func (c *Call) explain(method string, params []interface{}) Candidate {
+	cand := Candidate{Call: *c, Failed: -1, Exhausted: c.exhausted()}
+	params = gatherRest(c.Params, params)
+	switch {
+	case c.Params == nil:
+		cand.Score = 1
+		cand.Reason = "matches any parameters"
+	case len(c.Params) != len(params):
+		n := len(c.Params)
+		if _, ok := c.Params[n-1].(*RestMatcher); ok {
+			cand.Reason = fmt.Sprintf("expected at least %d %s; got %d",
+				n-1, plural(n-1, "parameter", "parameters"), len(params))
+		} else {
+			cand.Reason = fmt.Sprintf("expected %d %s; got %d",
+				n, plural(n, "parameter", "parameters"), len(params))
+		}
+	}
+
+	score := 0
+	var errs []error
+	for i, p := range params {
+		if i >= len(c.Params) {
+			break
+		}
+		success, err := matchParam(c.Params[i], p)
+		if err != nil {
+			success = false
+			errs = append(errs, &MatcherError{Method: method, Index: i, Err: err})
+		}
+		if success {
+			cand.Matching++
+			score += weight(c.Params[i])
+			continue
+		} else if cand.Failed >= 0 || cand.Reason != "" {
+			continue
+		}
+		cand.Failed = i
+		if err != nil {
+			cand.Reason = fmt.Sprintf("parameter %d: matcher failed: %s", i, err)
+		} else if fm, ok := c.Params[i].(failureMessager); ok {
+			cand.Reason = fmt.Sprintf("parameter %d: %s", i, fm.FailureMessage(paramFor(c.Params[i], p)))
+		} else {
+			cand.Reason = fmt.Sprintf("parameter %d: %#v did not match %#v", i, p, c.Params[i])
+		}
+	}
+	if c.Params != nil && len(c.Params) == len(params) && cand.Matching == len(params) {
+		cand.Score = score
+	}
+	cand.Err = errors.Join(errs...)
+
+	if cand.Exhausted {
+		cand.Reason = fmt.Sprintf("exhausted after %d %s", c.used(), plural(c.used(), "call", "calls"))
+	} else if cand.Reason == "" {
+		cand.Reason = "matches"
+	}
+	return cand
+}
+
+// The subset of gomega's Matcher interface that explains a failure.
+type failureMessager interface {
+	FailureMessage(actual interface{}) string
+}
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+// CallHistory is a list of calls observed by one or more spies, usually in the
+// order they were observed; see Spy.Calls and Spy.AllCalls. Its methods return
+// filtered copies of the history, so you can chain them:
+//
+//     recent := spy.AllCalls().Method("Add").Since(start)
+type CallHistory []RecordedCall
+
+// Filter returns the calls for which keep returns true.
+func (h CallHistory) Filter(keep func(RecordedCall) bool) CallHistory {
+	res := CallHistory{}
+	for _, c := range h {
+		if keep(c) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// Method returns the calls to any of the given methods.
+func (h CallHistory) Method(names ...string) CallHistory {
+	return h.Filter(func(c RecordedCall) bool {
+		for _, n := range names {
+			if c.Method == n {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Matching returns the calls whose params satisfy some criteria. As with
+// Spy.Count, params beyond the number of criteria are not considered.
+func (h CallHistory) Matching(criteria ...Matcher) CallHistory {
+	return h.Filter(func(c RecordedCall) bool {
+		return c.matches(criteria)
+	})
+}
+
+// Since returns the calls that were observed at or after a point in time.
+func (h CallHistory) Since(t time.Time) CallHistory {
+	return h.Filter(func(c RecordedCall) bool {
+		return !c.Time.Before(t)
+	})
+}
+
+// OnGoroutine returns the calls that were made by a goroutine.
+func (h CallHistory) OnGoroutine(id uint64) CallHistory {
+	return h.Filter(func(c RecordedCall) bool {
+		return c.Goroutine == id
+	})
+}
+
+// String describes each call on a separate line.
+func (h CallHistory) String() string {
+	lines := make([]string, len(h))
+	for i, c := range h {
+		lines[i] = c.String()
+	}
+	return strings.Join(lines, "\n")
+}
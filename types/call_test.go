@@ -10,40 +10,47 @@ var _ = Describe("Call", func() {
 		wide := []interface{}{uint64(0), int64(1), float64(2.0)}
 		narrow := []interface{}{uint8(0), int8(1), float32(2.0)}
 		c := Call{Params: MatchParams(wide)}
-		Expect(c.score(narrow)).NotTo(BeZero())
+		Expect(c.score("Foo", narrow)).NotTo(BeZero())
 	})
 
 	It("scores 1 given no params", func() {
 		params := []interface{}{}
 		params2 := []interface{}{1, 2, 3, 4, 5}
 		c := Call{}
-		Expect(c.score(params)).To(Equal(1))
-		Expect(c.score(params2)).To(Equal(1))
+		Expect(c.score("Foo", params)).To(Equal(1))
+		Expect(c.score("Foo", params2)).To(Equal(1))
 	})
 
 	It("scores equality higher than equivalence", func() {
 		c := Call{
-			Params: []Matcher{Equal(42), Equal(true)},
+			Params: []Matcher{&EqualMatcher{Expected: 42}, &EqualMatcher{Expected: true}},
 		}
 		c2 := Call{
-			Params: []Matcher{BeEquivalentTo(42.0), BeEquivalentTo(true)},
+			Params: []Matcher{&EquivalentMatcher{Expected: 42.0}, &EquivalentMatcher{Expected: true}},
 		}
-		high := c.score([]interface{}{42, true})
-		low := c2.score([]interface{}{42, true})
+		high := c.score("Foo", []interface{}{42, true})
+		low := c2.score("Foo", []interface{}{42, true})
 		Expect(low).To(BeNumerically(">", 0))
 		Expect(high).To(BeNumerically(">", low))
 	})
 
 	It("scores equivalence higher than other matches", func() {
 		c := Call{
-			Params: []Matcher{BeEquivalentTo(42.0), BeEquivalentTo(true)},
+			Params: []Matcher{&EquivalentMatcher{Expected: 42.0}, &EquivalentMatcher{Expected: true}},
 		}
 		c2 := Call{
 			Params: []Matcher{BeNumerically(">", 12.0), BeTrue()},
 		}
-		high := c.score([]interface{}{42, true})
-		low := c2.score([]interface{}{42, true})
+		high := c.score("Foo", []interface{}{42, true})
+		low := c2.score("Foo", []interface{}{42, true})
 		Expect(low).To(BeNumerically(">", 0))
 		Expect(high).To(BeNumerically(">", low))
 	})
+
+	It("scores Gomega's matchers as it scores its own", func() {
+		c := Call{
+			Params: []Matcher{Equal(42), BeNil(), BeEquivalentTo(1.0), BeNumerically(">", 0)},
+		}
+		Expect(c.score("Foo", []interface{}{42, nil, 1, 1})).To(Equal(4 + 4 + 3 + 2))
+	})
 })
@@ -0,0 +1,191 @@
+package types
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Captor is a parameter matcher that matches any value and records the
+// values of the calls that it matched, so that you can inspect them later.
+// The zero value is ready to use; pass a pointer to With:
+//
+//     name := &types.Captor{}
+//     Allow(double).Call("Greet").With(name).Return(true)
+//     subject.Run()
+//     Expect(name.Last()).To(Equal("Alice"))
+//
+// When used with Allow, a captor records a value whenever its allowed call is
+// chosen to handle a method call; it does not record calls that merely
+// competed to handle one. When used with HaveCall, a captor holds the values
+// of every recorded call that satisfied the matcher, replacing any values
+// that it held before.
+type Captor struct {
+	mutex  sync.Mutex
+	values []interface{}
+}
+
+// Match always succeeds.
+func (c *Captor) Match(actual interface{}) (bool, error) {
+	return true, nil
+}
+
+// All returns every captured value, in the order captured.
+func (c *Captor) All() []interface{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]interface{}{}, c.values...)
+}
+
+// Len returns the number of captured values.
+func (c *Captor) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.values)
+}
+
+// At returns the i'th captured value. It panics if fewer than i+1 values have
+// been captured.
+func (c *Captor) At(i int) interface{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if i < 0 || i >= len(c.values) {
+		misuse("At", "cannot get value %d of a Captor that has captured %d", i, len(c.values))
+	}
+	return c.values[i]
+}
+
+// Last returns the most recently captured value, or nil if none has been
+// captured.
+func (c *Captor) Last() interface{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.values) == 0 {
+		return nil
+	}
+	return c.values[len(c.values)-1]
+}
+
+func (c *Captor) capture(v interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.values = append(c.values, v)
+}
+
+func (c *Captor) set(vs []interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.values = append([]interface{}{}, vs...)
+}
+
+// CaptorOf is a Captor whose accessors return values of type T. Unlike
+// Captor, it only matches values of type T (or nil, if T is a pointer,
+// interface or other nillable type).
+//
+//     name := &types.CaptorOf[string]{}
+//     Allow(double).Call("Greet").With(name).Return(true)
+//     subject.Run()
+//     Expect(name.Last()).To(Equal("Alice"))
+type CaptorOf[T any] struct {
+	mutex  sync.Mutex
+	values []T
+}
+
+// Match succeeds if actual is a T.
+func (c *CaptorOf[T]) Match(actual interface{}) (bool, error) {
+	_, ok := c.convert(actual)
+	return ok, nil
+}
+
+// All returns every captured value, in the order captured.
+func (c *CaptorOf[T]) All() []T {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]T{}, c.values...)
+}
+
+// Len returns the number of captured values.
+func (c *CaptorOf[T]) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.values)
+}
+
+// At returns the i'th captured value. It panics if fewer than i+1 values have
+// been captured.
+func (c *CaptorOf[T]) At(i int) T {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if i < 0 || i >= len(c.values) {
+		misuse("At", "cannot get value %d of a CaptorOf that has captured %d", i, len(c.values))
+	}
+	return c.values[i]
+}
+
+// Last returns the most recently captured value, or the zero value of T if
+// none has been captured.
+func (c *CaptorOf[T]) Last() T {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var zero T
+	if len(c.values) == 0 {
+		return zero
+	}
+	return c.values[len(c.values)-1]
+}
+
+// Converts a value to T, reporting whether that was possible.
+func (c *CaptorOf[T]) convert(v interface{}) (T, bool) {
+	if t, ok := v.(T); ok {
+		return t, true
+	}
+	var zero T
+	if v == nil {
+		switch reflect.TypeOf(&zero).Elem().Kind() {
+		case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+			return zero, true
+		}
+	}
+	return zero, false
+}
+
+func (c *CaptorOf[T]) capture(v interface{}) {
+	t, _ := c.convert(v)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.values = append(c.values, t)
+}
+
+func (c *CaptorOf[T]) set(vs []interface{}) {
+	ts := make([]T, len(vs))
+	for i, v := range vs {
+		ts[i], _ = c.convert(v)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.values = ts
+}
+
+// A Matcher that records the values it matches; see Captor.
+type capturer interface {
+	Matcher
+	// Record the value of a parameter.
+	capture(v interface{})
+	// Replace all recorded values.
+	set(vs []interface{})
+}
+
+// Matches a parameter against a matcher. Numeric parameters are widened
+// (see widen) except for captors, which record the original value, and
+// TypeOfMatchers, which check it.
+func matchParam(m Matcher, p interface{}) (bool, error) {
+	return m.Match(paramFor(m, p))
+}
+
+// Returns a parameter as a matcher should see it; see matchParam.
+func paramFor(m Matcher, p interface{}) interface{} {
+	switch m.(type) {
+	case capturer, *TypeOfMatcher:
+		return p
+	}
+	return widen(p)
+}
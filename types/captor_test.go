@@ -0,0 +1,82 @@
+package types_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/xeger/gomuti"
+	"github.com/xeger/gomuti/types"
+)
+
+var _ = Describe("Captor", func() {
+	var double *hammered
+
+	BeforeEach(func() {
+		double = &hammered{}
+	})
+
+	Context("with Allow", func() {
+		It("captures values of the calls it handles", func() {
+			c := &types.Captor{}
+			Allow(double).Call("Add").With(c, 1).Return(42)
+			Allow(double).Call("Add").With(Anything(), 2).Return(0)
+
+			Expect(double.Add(7, 1)).To(Equal(42))
+			Expect(double.Add(8, 2)).To(Equal(0))
+			Expect(double.Add(9, 1)).To(Equal(42))
+
+			Expect(c.All()).To(Equal([]interface{}{7, 9}))
+			Expect(c.Len()).To(Equal(2))
+			Expect(c.At(0)).To(Equal(7))
+			Expect(c.Last()).To(Equal(9))
+		})
+
+		It("panics when asked for a value it did not capture", func() {
+			c := &types.Captor{}
+			Expect(c.Last()).To(BeNil())
+			Expect(func() { c.At(0) }).To(PanicWith(BeAssignableToTypeOf(&types.DSLMisuseError{})))
+		})
+	})
+
+	Context("with HaveCall", func() {
+		It("holds the values of matching calls", func() {
+			double.Spy.Init().Observe("Add", 1, 2)
+			double.Spy.Init().Observe("Add", 3, 4)
+			double.Spy.Init().Observe("Add", 5, 2)
+
+			c := &types.Captor{}
+			Expect(double).To(HaveCall("Add").With(c, 2).Times(2))
+			Expect(double).To(HaveCall("Add").With(c, 2).Times(2))
+			Expect(c.All()).To(Equal([]interface{}{1, 5}))
+		})
+	})
+
+	Context("CaptorOf", func() {
+		It("returns typed values", func() {
+			c := &types.CaptorOf[int]{}
+			Allow(double).Call("Add").With(c, Anything()).Return(1)
+
+			double.Add(3, 4)
+			double.Add(5, 6)
+
+			Expect(c.All()).To(Equal([]int{3, 5}))
+			Expect(c.At(0)).To(Equal(3))
+			Expect(c.Last()).To(Equal(5))
+		})
+
+		It("matches only values of its type", func() {
+			Expect((&types.CaptorOf[string]{}).Match(1)).To(BeFalse())
+			Expect((&types.CaptorOf[error]{}).Match(nil)).To(BeTrue())
+			Expect((&types.CaptorOf[int]{}).Match(nil)).To(BeFalse())
+		})
+
+		It("works with HaveCall", func() {
+			s := types.Spy{}
+			s.Observe("Greet", "Alice")
+			s.Observe("Greet", "Bob")
+
+			c := &types.CaptorOf[string]{}
+			Expect(s).To(HaveCall("Greet").With(c).Twice())
+			Expect(c.All()).To(Equal([]string{"Alice", "Bob"}))
+		})
+	})
+})
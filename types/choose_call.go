@@ -3,4 +3,8 @@ package types
 // ChooseCall is a tiebreaker when several allowed calls match a given set of
 // parameters. If nil, the default behavior is to choose the most recently
 // allowed call.
+//
+// Deprecated: ChooseCall is shared by every Mock, so tests that run in
+// parallel interfere with one another. Use Mock.SetTiebreak instead; a Mock
+// consults ChooseCall only if it has no tiebreak of its own.
 var ChooseCall func([]Call) Call
@@ -0,0 +1,107 @@
+package types
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells a Mock how long to wait before a call that was programmed with
+// Allowed.Delay or Allowed.DelayRandom behaves. Use a FakeClock to test
+// latency without sleeping.
+type Clock interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+// A Clock that can forget a channel returned by After that nobody waits for
+// any more, e.g. because a delayed call's context was cancelled.
+type stopper interface {
+	stop(ch <-chan time.Time)
+}
+
+// The real time, as told by package time; used by every Mock that has no
+// clock of its own (see Mock.SetClock).
+type systemClock struct{}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// FakeClock is a Clock whose time only passes when you call Advance. Its zero
+// value is ready to use.
+//
+//     clock := &types.FakeClock{}
+//     double.Mock.SetClock(clock)
+//     Allow(double).Call("Fetch").Delay(time.Minute).Return(page, nil)
+//
+//     go subject.Fetch()
+//     Eventually(clock.Waiters).Should(Equal(1))
+//     clock.Advance(time.Minute)
+type FakeClock struct {
+	mutex   sync.Mutex
+	now     time.Duration
+	waiters []fakeWaiter
+}
+
+// A channel returned by FakeClock.After, and the time at which it fires.
+type fakeWaiter struct {
+	at time.Duration
+	ch chan time.Time
+}
+
+// After returns a channel that receives the clock's time once the clock has
+// advanced by d.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.time()
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now + d, ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward, firing the channels of every waiter whose
+// time has come.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now += d
+	sort.SliceStable(c.waiters, func(i, j int) bool { return c.waiters[i].at < c.waiters[j].at })
+	n := 0
+	for n < len(c.waiters) && c.waiters[n].at <= c.now {
+		c.waiters[n].ch <- c.time()
+		n++
+	}
+	c.waiters = c.waiters[n:]
+}
+
+// Forgets a channel returned by After, so that it no longer counts among the
+// waiters.
+func (c *FakeClock) stop(ch <-chan time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i, w := range c.waiters {
+		if w.ch == ch {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// Waiters returns the number of channels returned by After that have yet to
+// fire. It tells you how many delayed calls are waiting for the clock.
+func (c *FakeClock) Waiters() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.waiters)
+}
+
+// Returns the current time of the clock. The caller must hold its mutex.
+func (c *FakeClock) time() time.Time {
+	return time.Unix(0, 0).Add(c.now)
+}
@@ -0,0 +1,164 @@
+package types_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/xeger/gomuti"
+	"github.com/xeger/gomuti/types"
+)
+
+var _ = Describe("FakeClock", func() {
+	It("fires channels when it advances far enough", func() {
+		clock := &types.FakeClock{}
+		soon, later := clock.After(time.Second), clock.After(time.Minute)
+		Expect(clock.Waiters()).To(Equal(2))
+
+		clock.Advance(30 * time.Second)
+		Expect(soon).To(Receive(Equal(time.Unix(30, 0))))
+		Expect(later).NotTo(Receive())
+		Expect(clock.Waiters()).To(Equal(1))
+
+		clock.Advance(30 * time.Second)
+		Expect(later).To(Receive())
+		Expect(clock.After(0)).To(Receive())
+	})
+})
+
+var _ = Describe("Allowed", func() {
+	var m types.Mock
+	var clock *types.FakeClock
+
+	BeforeEach(func() {
+		m = types.Mock{}
+		clock = &types.FakeClock{}
+		m.SetClock(clock)
+	})
+
+	// Calls the mock in another goroutine and returns a channel that receives
+	// the results.
+	call := func(method string, params ...interface{}) <-chan []interface{} {
+		ch := make(chan []interface{}, 1)
+		go func(m types.Mock) {
+			defer GinkgoRecover()
+			ch <- m.Call(method, params...)
+		}(m)
+		return ch
+	}
+
+	Context("Delay", func() {
+		It("waits for the clock before behaving", func() {
+			Â(m).Call("Fetch").Delay(time.Minute).Return("page")
+			res := call("Fetch")
+			Eventually(clock.Waiters).Should(Equal(1))
+			Consistently(res).ShouldNot(Receive())
+
+			clock.Advance(time.Minute)
+			Eventually(res).Should(Receive(Equal([]interface{}{"page"})))
+		})
+
+		It("applies to one behavior in a sequence", func() {
+			Â(m).Call("Fetch").Return(1).Then().Delay(time.Second).Return(2)
+			Expect(m.Call("Fetch")).To(Equal([]interface{}{1}))
+			res := call("Fetch")
+			Eventually(clock.Waiters).Should(Equal(1))
+			clock.Advance(time.Second)
+			Eventually(res).Should(Receive(Equal([]interface{}{2})))
+		})
+
+		It("stops waiting when a context is cancelled", func() {
+			Â(m).Call("Fetch").Delay(time.Hour).Return("page")
+			ctx, cancel := context.WithCancel(context.Background())
+			res := call("Fetch", ctx)
+			Eventually(clock.Waiters).Should(Equal(1))
+			Consistently(res).ShouldNot(Receive())
+			cancel()
+			Eventually(res).Should(Receive(Equal([]interface{}{"page"})))
+			Expect(clock.Waiters()).To(Equal(0))
+		})
+
+		It("cannot be combined with Hang", func() {
+			Expect(func() {
+				Â(m).Call("Fetch").Delay(time.Second).Hang()
+			}).To(PanicWith(BeAssignableToTypeOf(&types.DSLMisuseError{})))
+			Expect(func() {
+				Â(m).Call("Fetch").Hang().Delay(time.Second)
+			}).To(PanicWith(BeAssignableToTypeOf(&types.DSLMisuseError{})))
+		})
+	})
+
+	Context("DelayRandom", func() {
+		It("waits for repeatable durations within bounds", func() {
+			Â(m).Call("Fetch").DelayRandom(time.Second, 2*time.Second, 42)
+
+			for i := 0; i < 3; i++ {
+				res := call("Fetch")
+				Eventually(clock.Waiters).Should(Equal(1))
+				clock.Advance(time.Second - 1)
+				Consistently(res).ShouldNot(Receive())
+				clock.Advance(time.Second)
+				Eventually(res).Should(Receive())
+			}
+		})
+
+		It("rejects empty ranges", func() {
+			Expect(func() {
+				Â(m).Call("Fetch").DelayRandom(time.Second, time.Second, 1)
+			}).To(PanicWith(BeAssignableToTypeOf(&types.DSLMisuseError{})))
+		})
+	})
+
+	Context("Hang", func() {
+		It("blocks until released", func() {
+			Â(m).Call("Fetch").Hang().Return("late")
+			res := call("Fetch")
+			Consistently(res).ShouldNot(Receive())
+			m.Release()
+			Eventually(res).Should(Receive(Equal([]interface{}{"late"})))
+		})
+
+		It("blocks until a context is cancelled", func() {
+			Â(m).Call("Fetch").Hang().Do(func(ctx context.Context) error {
+				return ctx.Err()
+			})
+			ctx, cancel := context.WithCancel(context.Background())
+			res := call("Fetch", ctx)
+			Consistently(res).ShouldNot(Receive())
+			cancel()
+			Eventually(res).Should(Receive(Equal([]interface{}{context.Canceled})))
+		})
+
+		It("blocks until the mock is reset", func() {
+			double := &hammered{Mock: m}
+			Allow(double).Call("Add").Hang().Return(3)
+			res := make(chan int, 1)
+			go func() { res <- double.Add(1, 2) }()
+			Consistently(res).ShouldNot(Receive())
+			Reset(double)
+			Eventually(res).Should(Receive(Equal(3)))
+		})
+
+		It("is released by a Release right after the call is matched", func() {
+			for i := 0; i < 100; i++ {
+				Â(m).Call("Fetch").Hang().Return(i)
+				res := call("Fetch")
+				Eventually(func() int { return m["Fetch"][0].Matched() }).Should(Equal(1))
+				m.Release()
+				Eventually(res).Should(Receive(Equal([]interface{}{i})))
+				m.Reset()
+			}
+		})
+
+		It("may be followed by other behaviors", func() {
+			Â(m).Call("Fetch").Hang().Then().Return("fast")
+			res := call("Fetch")
+			Eventually(m["Fetch"][0].Matched).Should(Equal(1))
+			Expect(m.Call("Fetch")).To(Equal([]interface{}{"fast"}))
+			Consistently(res).ShouldNot(Receive())
+			Release(m)
+			Eventually(res).Should(Receive(BeEmpty()))
+		})
+	})
+})
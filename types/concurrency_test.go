@@ -0,0 +1,119 @@
+package types_test
+
+import (
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/xeger/gomuti"
+	"github.com/xeger/gomuti/types"
+)
+
+// A hand-written test double, similar to the ones that gomuti-gen generates.
+type hammered struct {
+	Mock types.Mock
+	Spy  types.Spy
+}
+
+func (h *hammered) Add(l, r int) int {
+	h.Spy.Init().Observe("Add", l, r)
+	res := h.Mock.Call("Add", l, r)
+	if len(res) == 0 {
+		return 0
+	}
+	return res[0].(int)
+}
+
+// These tests are most useful when run with the race detector:
+//
+//     go test -race ./types
+var _ = Describe("concurrency", func() {
+	const goroutines = 20
+	const calls = 50
+
+	// Runs fn in many goroutines at once, and waits for them to finish.
+	hammer := func(fn func(g int)) {
+		var wg sync.WaitGroup
+		wg.Add(goroutines)
+		for g := 0; g < goroutines; g++ {
+			go func(g int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				fn(g)
+			}(g)
+		}
+		wg.Wait()
+	}
+
+	It("calls and observes from many goroutines", func() {
+		double := &hammered{}
+		Allow(double).Call("Add").With(Anything(), Anything()).Return(42)
+		Allow(double).Call("Add").With(0, 0).Once().Return(0)
+
+		hammer(func(g int) {
+			for i := 0; i < calls; i++ {
+				double.Add(g, i)
+			}
+		})
+
+		Expect(double).To(HaveCall("Add").Times(goroutines * calls))
+		Expect(double).To(HaveCall("Add").With(0, 0).Once())
+		Expect(Verify(double)).To(Succeed())
+	})
+
+	It("allows, calls and verifies from many goroutines", func() {
+		double := &hammered{}
+
+		hammer(func(g int) {
+			Allow(double).Call("Add").With(g, Anything()).Times(calls).Return(g)
+			for i := 0; i < calls; i++ {
+				Expect(double.Add(g, i)).To(Equal(g))
+			}
+			Expect(double).To(HaveCall("Add").With(g, Anything()).Times(calls))
+			Expect(double).To(HaveCallsInOrder(HaveCall("Add").With(g, 0), HaveCall("Add").With(g, calls-1)))
+		})
+
+		Expect(Verify(double)).To(Succeed())
+	})
+
+	It("programs each Allowed independently", func() {
+		m := types.Mock{}
+		first := m.Allow().Call("Add")
+		second := m.Allow().Call("Add")
+		first.With(1, 1).Return(2)
+		second.With(2, 2).Return(4)
+		Expect(m.Call("Add", 1, 1)).To(Equal([]interface{}{2}))
+		Expect(m.Call("Add", 2, 2)).To(Equal([]interface{}{4}))
+	})
+
+	It("lets matchers and tiebreaks call other doubles", func() {
+		other := &hammered{}
+		Allow(other).Call("Add").Return(1)
+
+		m := types.Mock{}
+		m.SetTiebreak(func(calls []types.Call) types.Call {
+			other.Add(0, 0)
+			return calls[0]
+		})
+		m.Allow().Call("Add").With(Satisfy(func(int) bool { return other.Add(1, 1) == 1 })).Return("first")
+		m.Allow().Call("Add").With(Satisfy(func(int) bool { return other.Add(2, 2) == 1 })).Return("second")
+
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			Expect(m.Call("Add", 1)).To(Equal([]interface{}{"first"}))
+			Expect(m.Explain("Add", 1).Candidates).To(HaveLen(2))
+		}()
+		Eventually(done).Should(BeClosed())
+		Expect(other).To(HaveCall("Add").With(0, 0).Once())
+	})
+
+	It("releases its lock when a matcher fails", func() {
+		m := types.Mock{}
+		m.Allow().Call("Add").With(BeNumerically(">", 0)).Return(1)
+		Expect(func() { m.Call("Add", "not a number") }).To(Panic())
+		Expect(m.Call("Add", 1)).To(Equal([]interface{}{1}))
+	})
+})
+
@@ -0,0 +1,139 @@
+package types
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Double is a test double that Gomuti builds at run time, for code that
+// depends upon functions rather than interfaces. Its Impl is either a function
+// of type T, or (if T is a struct) a T whose exported function-typed fields
+// are all set. Each function records its calls with the Spy and consults the
+// Mock for its results, just as the methods of a generated test double do.
+// Calls to a field are known by the field's name; calls to a function of type
+// T are known as "Func".
+//
+//     type Hooks struct {
+//       BeforeSave func(*Record) error
+//       AfterSave  func(*Record)
+//     }
+//
+//     hooks := NewDouble[Hooks]()
+//     Allow(hooks).Call("BeforeSave").Return(errReadOnly)
+//     store := NewStore(hooks.Impl)
+//     ...
+//     Expect(hooks).To(HaveCall("AfterSave").Never())
+//
+// Go cannot create methods at run time, so Double cannot implement an
+// interface; use gomuti-gen to generate doubles for interfaces.
+type Double[T any] struct {
+	Mock Mock
+	Spy  Spy
+	// If true, functions return zero values instead of panicking when no
+	// allowed call matches.
+	Stub bool
+	Impl T
+}
+
+// Method name under which a Double records calls to a function of type T.
+const funcMethod = "Func"
+
+// NewDouble builds a Double whose Impl is a function or a struct of
+// functions. It panics with a DSLMisuseError if T is anything else.
+func NewDouble[T any]() *Double[T] {
+	var zero T
+	d := &Double[T]{Mock: Mock{}, Spy: Spy{}}
+	d.Impl = d.build(reflect.TypeOf(&zero).Elem()).Interface().(T)
+	return d
+}
+
+// NewDoubleOf is like NewDouble, but takes the type of the Impl as a
+// reflect.Type, for use when the type is not known at compile time.
+func NewDoubleOf(t reflect.Type) *Double[interface{}] {
+	d := &Double[interface{}]{Mock: Mock{}, Spy: Spy{}}
+	d.Impl = d.build(t).Interface()
+	return d
+}
+
+// Builds the Impl of a Double.
+func (d *Double[T]) build(t reflect.Type) reflect.Value {
+	name := "Double[" + t.String() + "]"
+	switch t.Kind() {
+	case reflect.Func:
+		return d.makeFunc(name, t, funcMethod)
+	case reflect.Struct:
+		v := reflect.New(t).Elem()
+		n := 0
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath == "" && sf.Type.Kind() == reflect.Func {
+				v.Field(i).Set(d.makeFunc(name, sf.Type, sf.Name))
+				n++
+			}
+		}
+		if n == 0 {
+			misuse("NewDouble", "cannot build a double for %s, which has no exported fields of function type", t)
+		}
+		return v
+	case reflect.Interface:
+		misuse("NewDouble", "cannot implement interface %s at run time, because Go cannot create methods dynamically; generate a double for it with gomuti-gen", t)
+	default:
+		misuse("NewDouble", "cannot build a double for %s; expected a function type or a struct of functions", t)
+	}
+	return reflect.Value{}
+}
+
+// Makes a function of the given type that routes calls to the Double's Spy
+// and Mock under the given method name. Name describes the Double in errors.
+func (d *Double[T]) makeFunc(name string, ft reflect.Type, method string) reflect.Value {
+	return reflect.MakeFunc(ft, func(args []reflect.Value) []reflect.Value {
+		// A variadic parameter arrives as a slice, which we pass along as is, in
+		// the same way as generated test doubles.
+		params := make([]interface{}, len(args))
+		for i, a := range args {
+			params[i] = a.Interface()
+		}
+
+		file, line := callerOutsideReflect()
+		c := d.Spy.Init().begin(method, params, file, line)
+		defer c.Recover()
+
+		r := d.Mock.Call(method, params...)
+		if r == nil && !d.Stub {
+			e := d.Mock.Explain(method, params...)
+			e.Double = name
+			panic(e)
+		}
+
+		out := make([]reflect.Value, ft.NumOut())
+		results := make([]interface{}, len(out))
+		for i := range out {
+			t := ft.Out(i)
+			if i >= len(r) || r[i] == nil {
+				out[i] = reflect.Zero(t)
+			} else if v, ok := convertParam(r[i], t); ok {
+				out[i] = v
+			} else {
+				panic(&SignatureError{Method: method, Kind: "result", Index: i, Expected: t, Actual: reflect.TypeOf(r[i])})
+			}
+			results[i] = out[i].Interface()
+		}
+		c.Return(results...)
+		return out
+	})
+}
+
+// Returns the location of the code that called a function made by
+// reflect.MakeFunc, skipping the frames of package reflect.
+func callerOutsideReflect() (string, int) {
+	for skip := 2; ; skip++ {
+		pc, file, line, ok := runtime.Caller(skip)
+		if !ok {
+			return "", 0
+		}
+		if fn := runtime.FuncForPC(pc); fn == nil || !strings.HasPrefix(fn.Name(), "reflect.") {
+			return file, line
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package types_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/xeger/gomuti"
+	"github.com/xeger/gomuti/types"
+)
+
+type hooks struct {
+	BeforeSave func(name string) error
+	AfterSave  func(name string, sizes ...int)
+	Label      string
+	unexported func()
+}
+
+var _ = Describe("Double", func() {
+	Context("given a function type", func() {
+		It("routes calls to its Mock and Spy", func() {
+			double := New[func(string) (int, error)]()
+			Allow(double).Call("Func").With("42").Return(42, nil)
+			Allow(double).Call("Func").With("x").Return(0, errors.New("not a number"))
+
+			n, err := double.Impl("42")
+			Expect(n).To(Equal(42))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = double.Impl("x")
+			Expect(err).To(MatchError("not a number"))
+
+			Expect(double).To(HaveCall("Func").With("42").Returning(42, nil).Once())
+			Expect(double).To(HaveCall("Func").Twice())
+		})
+
+		It("records the location of its caller", func() {
+			double := New[func()]()
+			Allow(double).Call("Func")
+			double.Impl()
+			calls := double.Spy.Calls("Func")
+			Expect(calls).To(HaveLen(1))
+			Expect(filepath.Base(calls[0].File)).To(Equal("double_test.go"))
+		})
+
+		It("converts results to the function's types", func() {
+			double := New[func() (int8, error)]()
+			Allow(double).Call("Func").Return(7, nil)
+			n, err := double.Impl()
+			Expect(n).To(Equal(int8(7)))
+			Expect(err).To(BeNil())
+
+			Allow(double).Call("Func").Return("seven", nil)
+			Expect(func() {
+				double.Impl()
+			}).To(PanicWith(MatchError("gomuti: Func: result 0 should be int8, not string")))
+		})
+
+		It("panics unless a call matches or it is a stub", func() {
+			double := New[func(int) int]()
+			Expect(func() {
+				double.Impl(1)
+			}).To(PanicWith(MatchError(ContainSubstring("no behavior programmed for Double[func(int) int].Func(1)"))))
+
+			double.Stub = true
+			Expect(double.Impl(1)).To(Equal(0))
+		})
+	})
+
+	Context("given a struct of functions", func() {
+		It("sets its exported function fields", func() {
+			double := New[hooks]()
+			Expect(double.Impl.BeforeSave).NotTo(BeNil())
+			Expect(double.Impl.AfterSave).NotTo(BeNil())
+			Expect(double.Impl.unexported).To(BeNil())
+			Expect(double.Impl.Label).To(BeEmpty())
+		})
+
+		It("knows calls by the name of the field", func() {
+			double := New[hooks]()
+			Allow(double).Call("BeforeSave").With("readonly").Return(errors.New("read only"))
+			Allow(double).Call("BeforeSave").Return(nil)
+			Allow(double).Call("AfterSave")
+
+			Expect(double.Impl.BeforeSave("readonly")).To(MatchError("read only"))
+			Expect(double.Impl.BeforeSave("other")).To(Succeed())
+			double.Impl.AfterSave("other", 1, 2)
+
+			Expect(double).To(HaveCall("AfterSave").With("other", VariadicWith(1, 2)).Once())
+		})
+	})
+
+	Context("given a reflect.Type", func() {
+		It("builds the same kind of double", func() {
+			double := Double(reflect.TypeOf(fmt.Sprint))
+			Allow(double).Call("Func").Return("hello")
+			Expect(double.Impl.(func(...interface{}) string)(1, 2)).To(Equal("hello"))
+			Expect(double).To(HaveCall("Func").With(Rest(HaveLen(2))))
+		})
+	})
+
+	It("refuses to implement interfaces", func() {
+		Expect(func() {
+			New[fmt.Stringer]()
+		}).To(PanicWith(MatchError(ContainSubstring("generate a double for it with gomuti-gen"))))
+		Expect(func() {
+			New[int]()
+		}).To(PanicWith(BeAssignableToTypeOf(&types.DSLMisuseError{})))
+		Expect(func() {
+			New[struct{ Label string }]()
+		}).To(PanicWith(BeAssignableToTypeOf(&types.DSLMisuseError{})))
+	})
+})
@@ -0,0 +1,272 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Gomuti reports misuse of its DSL, and method calls that a test double
+// cannot handle, by panicking with one of the error types below. Each of them
+// is panicked as a pointer and carries structured fields that describe the
+// problem, so tests can tell them apart without parsing messages:
+//
+//     defer func() {
+//       var unmatched *types.UnmatchedCallError
+//       if err, ok := recover().(error); ok && errors.As(err, &unmatched) {
+//         fmt.Println("no behavior for", unmatched.Method, unmatched.Params)
+//       }
+//     }()
+//
+// Matchers such as Gomega's PanicWith(BeAssignableToTypeOf(...)) work too.
+
+// UnmatchedCallError describes a method call that matched none of the calls
+// allowed on a Mock; see Mock.Explain. Generated test doubles panic with this
+// error unless their Stub field is set.
+type UnmatchedCallError struct {
+	// Type of the test double, if known.
+	Double string
+	Method string
+	Params []interface{}
+	// Every call that was allowed for the method, in the order it was allowed,
+	// with an explanation of why it did not match.
+	Candidates []Candidate
+	// Index into Candidates of the call that came closest to matching, or -1
+	// if none came close.
+	Closest int
+}
+
+// Candidate explains why an allowed call did not match a method call.
+type Candidate struct {
+	Call Call
+	// Score of the call for the method call's params; see Allowed for the
+	// scoring rules. An exhausted call may have a positive score.
+	Score int
+	// Number of params that the call's matchers accepted.
+	Matching int
+	// Index of the first param that the call's matchers rejected, or -1 if
+	// none was rejected (e.g. because the number of params differed).
+	Failed int
+	// Human-readable explanation of the mismatch; if the matcher for the Failed
+	// param has a FailureMessage, this is it.
+	Reason string
+	// MatcherErrors of the matchers that failed to match a param, joined with
+	// errors.Join; or nil if none failed.
+	Err error
+	// True if the call would have matched, but was exhausted (see Times).
+	Exhausted bool
+}
+
+func (e *UnmatchedCallError) Error() string {
+	name := e.Method
+	if e.Double != "" {
+		name = e.Double + "." + e.Method
+	}
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "gomuti: no behavior programmed for %s(%s)", name, formatParams(e.Params))
+	if len(e.Candidates) == 0 {
+		fmt.Fprintf(b, "; no calls to %s were allowed", e.Method)
+		return b.String()
+	}
+	b.WriteString("; allowed calls:")
+	for i, c := range e.Candidates {
+		fmt.Fprintf(b, "\n  #%d (score %d): %s", i, c.Score, indent(c.Reason, "    "))
+	}
+	if e.Closest >= 0 {
+		fmt.Fprintf(b, "\nclosest match is #%d", e.Closest)
+	}
+	return b.String()
+}
+
+// AmbiguousMatchError describes a method call that matched several allowed
+// calls equally well.
+type AmbiguousMatchError struct {
+	Method string
+	Params []interface{}
+	// Calls that tied for the best match, in the order they were allowed.
+	Candidates []Call
+}
+
+func (e *AmbiguousMatchError) Error() string {
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "gomuti: ambiguous match for %s(%s); %d allowed calls match equally well:",
+		e.Method, formatParams(e.Params), len(e.Candidates))
+	for i, c := range e.Candidates {
+		fmt.Fprintf(b, "\n  #%d: %s", i, formatMatchers(c.Params))
+	}
+	return b.String()
+}
+
+// MatcherError describes a matcher that failed with an error, rather than
+// accepting or rejecting a parameter of a method call; for instance,
+// Numerically(">", "x") cannot compare anything to "x".
+type MatcherError struct {
+	Method string
+	// Position of the parameter.
+	Index int
+	Err   error
+}
+
+func (e *MatcherError) Error() string {
+	return fmt.Sprintf("gomuti: %s: matcher for parameter %d failed: %s", e.Method, e.Index, e.Err)
+}
+
+// Unwrap returns the error of the matcher.
+func (e *MatcherError) Unwrap() error {
+	return e.Err
+}
+
+// BadDoSignatureError describes a function passed to Allowed.Do that cannot
+// be called with the parameters of a method call, or whose results cannot be
+// returned.
+type BadDoSignatureError struct {
+	// Type of the function passed to Do; nil if it was not a function at all.
+	Func   reflect.Type
+	Params []interface{}
+	Reason string
+}
+
+func (e *BadDoSignatureError) Error() string {
+	if e.Func == nil {
+		return "gomuti: " + e.Reason
+	}
+	return fmt.Sprintf("gomuti: cannot use %s with Do(): %s", e.Func, e.Reason)
+}
+
+// SignatureError describes parameters or results, specified with With or
+// Return, or a function passed to Do, that do not fit the signature of the
+// method being mocked.
+type SignatureError struct {
+	Method string
+	// "parameter" or "result"; or "Do() parameter" or "Do() result" if the
+	// function passed to Do does not fit
+	Kind string
+	// Position of the parameter or result that does not fit, or -1 if the
+	// number of values is wrong.
+	Index int
+	// Expected and actual type of the value at Index; or, if Index is -1, the
+	// expected and actual number of values.
+	Expected, Actual reflect.Type
+	Want, Got        int
+}
+
+func (e *SignatureError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("gomuti: %s: expected %d %s; got %d",
+			e.Method, e.Want, plural(e.Want, e.Kind, e.Kind+"s"), e.Got)
+	}
+	actual := "nil"
+	if e.Actual != nil {
+		actual = e.Actual.String()
+	}
+	return fmt.Sprintf("gomuti: %s: %s %d should be %s, not %s", e.Method, e.Kind, e.Index, e.Expected, actual)
+}
+
+// SetArgError describes a value, passed to Allowed.SetArg or Allowed.CopyInto,
+// that cannot be written through a parameter of the method being mocked.
+type SetArgError struct {
+	Method string
+	// Position of the parameter.
+	Index int
+	// Type of the parameter, if known.
+	Param  reflect.Type
+	Value  interface{}
+	Reason string
+}
+
+func (e *SetArgError) Error() string {
+	if e.Param == nil {
+		return fmt.Sprintf("gomuti: %s: cannot set parameter %d: %s", e.Method, e.Index, e.Reason)
+	}
+	return fmt.Sprintf("gomuti: %s: cannot set parameter %d (%s): %s", e.Method, e.Index, e.Param, e.Reason)
+}
+
+// WaitTimeoutError describes a call to Spy.WaitFor that timed out before the
+// spy observed enough matching calls.
+type WaitTimeoutError struct {
+	Method    string
+	Want, Got int
+	Timeout   time.Duration
+}
+
+func (e *WaitTimeoutError) Error() string {
+	return fmt.Sprintf("gomuti: timed out after %s waiting for %d matching %s to %s; observed %d",
+		e.Timeout, e.Want, plural(e.Want, "call", "calls"), e.Method, e.Got)
+}
+
+// UninitializedError describes a nil Mock or Spy (or a test double that
+// contains one) that Gomuti cannot initialize on its own.
+type UninitializedError struct {
+	// Type of the value that must be initialized, e.g. "*types.Mock".
+	Type string
+	// Field of Type that holds the Mock or Spy, if any.
+	Field string
+	// Method that was called on the uninitialized value, if any.
+	Method string
+}
+
+func (e *UninitializedError) Error() string {
+	var what string
+	if e.Field != "" {
+		what = fmt.Sprintf("%s.%s (or pass a pointer to %s)", e.Type, e.Field, e.Type)
+	} else {
+		what = e.Type
+	}
+	msg := "gomuti: must initialize " + what + " before calling"
+	if e.Method != "" {
+		msg += " " + e.Method
+	}
+	return msg
+}
+
+// DSLMisuseError describes an invalid use of the Gomuti DSL, such as calling
+// a method of Allowed twice or in the wrong order, or passing a value that
+// Gomuti does not know how to work with.
+type DSLMisuseError struct {
+	// DSL method that was misused, e.g. "With".
+	Method string
+	Reason string
+}
+
+func (e *DSLMisuseError) Error() string {
+	return "gomuti: " + e.Reason
+}
+
+// Panics with a DSLMisuseError.
+func misuse(method, format string, args ...interface{}) {
+	panic(&DSLMisuseError{Method: method, Reason: fmt.Sprintf(format, args...)})
+}
+
+// Describes the parameter matchers of an allowed call.
+func formatMatchers(params []Matcher) string {
+	if params == nil {
+		return "any parameters"
+	}
+	s := make([]string, len(params))
+	for i, p := range params {
+		s[i] = fmt.Sprintf("%#v", p)
+	}
+	return "With(" + strings.Join(s, ", ") + ")"
+}
+
+func formatParams(params []interface{}) string {
+	s := make([]string, len(params))
+	for i, p := range params {
+		s[i] = fmt.Sprintf("%#v", p)
+	}
+	return strings.Join(s, ", ")
+}
+
+// Indents all but the first line of s with a prefix.
+func indent(s, prefix string) string {
+	return strings.Replace(strings.TrimRight(s, "\n"), "\n", "\n"+prefix, -1)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
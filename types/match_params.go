@@ -1,14 +1,10 @@
 package types
 
-import (
-	"reflect"
-
-	"github.com/onsi/gomega"
-)
+import "reflect"
 
 // MatchParams returns a sequence of gomuti Matchers that will match the specified
 // method-parameter sequence. For parameters that are not already a Matcher,
-// it uses a heuristic to create an equality, equivalency or be-nil matcher. For
+// it uses a heuristic to create an EqualMatcher, EquivalentMatcher or NilMatcher. For
 // parameters that are already a matcher, it returns the matcher verbatim.
 func MatchParams(params []interface{}) []Matcher {
 	matchers := make([]Matcher, len(params))
@@ -17,13 +13,13 @@ func MatchParams(params []interface{}) []Matcher {
 		if ok {
 			matchers[i] = m
 		} else if p == nil {
-			matchers[i] = gomega.BeNil()
+			matchers[i] = &NilMatcher{}
 		} else {
 			switch reflect.TypeOf(p).Kind() {
 			case reflect.Array, reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice, reflect.Struct, reflect.UnsafePointer:
-				matchers[i] = gomega.BeEquivalentTo(p)
+				matchers[i] = &EquivalentMatcher{Expected: p}
 			default:
-				matchers[i] = gomega.Equal(widen(p))
+				matchers[i] = &EqualMatcher{Expected: p}
 			}
 		}
 	}
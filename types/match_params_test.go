@@ -14,7 +14,7 @@ var _ = Describe("MatchParams", func() {
 	It("matches basic-type values using equality", func() {
 		out := types.MatchParams([]interface{}{1, true, 2.0, "hi"})
 		for _, m := range out {
-			Expect(m).To(HaveType("*matchers.EqualMatcher"))
+			Expect(m).To(HaveType("*types.EqualMatcher"))
 		}
 	})
 
@@ -40,7 +40,7 @@ var _ = Describe("MatchParams", func() {
 			map[string]bool{"Joe": true},
 		})
 		for _, m := range out {
-			Expect(m).To(HaveType("*matchers.BeEquivalentToMatcher"))
+			Expect(m).To(HaveType("*types.EquivalentMatcher"))
 		}
 	})
 
@@ -49,7 +49,7 @@ var _ = Describe("MatchParams", func() {
 			nil,
 		})
 		for _, m := range out {
-			Expect(m).To(HaveType("*matchers.BeNilMatcher"))
+			Expect(m).To(HaveType("*types.NilMatcher"))
 		}
 	})
 })
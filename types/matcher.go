@@ -7,3 +7,14 @@ package types
 type Matcher interface {
 	Match(actual interface{}) (success bool, err error)
 }
+
+// WeightedMatcher is a Matcher that tells how specific it is, so that a Mock
+// can prefer the most specific of several allowed calls that match (see
+// Allowed for the scoring rules). Matchers that do not implement it weigh 2,
+// except for Gomega's Equal and BeNil, which weigh 4, and BeEquivalentTo,
+// which weighs 3, as do their Gomuti equivalents. Weights below 1 count as 1, since a score of 0 means
+// that a call does not match at all.
+type WeightedMatcher interface {
+	Matcher
+	Weight() int
+}
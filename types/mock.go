@@ -1,7 +1,7 @@
 package types
 
 import (
-	"fmt"
+	"context"
 	"reflect"
 	"strings"
 )
@@ -24,6 +24,15 @@ func (m Mock) Allow() *Allowed {
 	return &Allowed{mock: m}
 }
 
+// AllowFor is like Allow, but remembers the type of the test double that
+// contains the Mock. If the double has a method with the name passed to
+// Allowed.Call, then Allowed.Do and Allowed.Return check their functions and
+// results against that method's signature, and panic with a SignatureError
+// if they do not fit.
+func (m Mock) AllowFor(double interface{}) *Allowed {
+	return &Allowed{mock: m, double: reflect.TypeOf(double)}
+}
+
 // Call informs the mock that a call has been made; if the call matches
 // a call that was programmed with Allow(), it returns non-nil. Methods
 // that return nothing, still return an empty slice if the call was matched.
@@ -36,52 +45,269 @@ func (m Mock) Call(method string, params ...interface{}) []interface{} {
 		return nil
 	}
 
-	c := m.bestMatch(method, params...)
-	if c != nil {
-		if c.Do != nil {
-			return c.Do(params...)
-		} else if c.Panic != nil {
-			panic(c.Panic)
-		} else if c.Results != nil {
-			return c.Results
+	b, release, ok := m.dispatch(method, params)
+	if ok {
+		m.wait(&b, release, params)
+		for _, w := range b.writes {
+			w.apply(method, params)
+		}
+		if b.Do != nil {
+			return b.Do(params...)
+		} else if b.Panic != nil {
+			panic(b.Panic)
+		} else if b.Results != nil {
+			return b.Results
 		}
 		// Lazy user didn't tell us to do, panic or return; assume he meant to
 		// return nothing
 		return defaultReturn
 	}
+	if d := m.getDelegate(); d != nil {
+		return CallThrough(d, method, params...)
+	}
 	return nil
 }
 
+// Blocks for as long as a call's behavior says to: until its Delay has
+// elapsed, or until a Hang is released by closing release. Either way, stops
+// waiting if a context.Context param is cancelled.
+func (m Mock) wait(b *Call, release <-chan struct{}, params []interface{}) {
+	if b.Delay == nil && !b.Hang {
+		return
+	}
+
+	var done <-chan struct{}
+	for _, p := range params {
+		if ctx, ok := p.(context.Context); ok {
+			done = ctx.Done()
+			break
+		}
+	}
+
+	if b.Hang {
+		select {
+		case <-release:
+		case <-done:
+		}
+	} else if d := b.Delay(); d > 0 {
+		clock := m.clock()
+		ch := clock.After(d)
+		select {
+		case <-ch:
+		case <-done:
+			if s, ok := clock.(stopper); ok {
+				s.stop(ch)
+			}
+		}
+	}
+}
+
+// Returns the Mock's delegate, if any.
+func (m Mock) getDelegate() interface{} {
+	l := m.lock()
+	l.RLock()
+	defer l.RUnlock()
+	return m.delegate()
+}
+
+// CallThrough calls a method of a real implementation, passing it the params
+// of a method call as Allowed.Do would, and returns its results. It panics
+// with a DSLMisuseError if the implementation has no such method.
+//
+// Mocks use it to forward calls to their delegate (see Mock.SetDelegate);
+// generated test doubles use it to forward calls to their Delegate field.
+func CallThrough(delegate interface{}, method string, params ...interface{}) []interface{} {
+	fn := reflect.ValueOf(delegate).MethodByName(method)
+	if !fn.IsValid() {
+		misuse("CallThrough", "cannot call %s through to %T, which has no such method", method, delegate)
+	}
+	return do(fn.Interface())(params...)
+}
+
+// Chooses the call that should handle a method call, records its use and
+// returns a copy of the behavior to use, together with the channel that
+// releases it if it hangs; returns false if no call matched.
+//
+// The channel is obtained under the same lock as the call, so that a Release
+// that comes after the call was chosen is never missed.
+func (m Mock) dispatch(method string, params []interface{}) (Call, <-chan struct{}, bool) {
+	l := m.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	c, spent := m.bestMatch(method, params...)
+	if c != nil {
+		c.capture(params)
+		b := *c.use()
+		var release <-chan struct{}
+		if b.Hang {
+			release = m.released()
+		}
+		return b, release, true
+	} else if spent != nil && spent.stats != nil {
+		// Nothing else could handle the call; blame the exhausted call that
+		// would otherwise have been chosen.
+		spent.stats.excess.Add(1)
+	}
+	return Call{}, nil, false
+}
+
 // Finds the closest matching call for the specified method, or nil if no
-// calls match. Calls ChooseCall() as a tiebreaker for matching calls.
-func (m Mock) bestMatch(method string, params ...interface{}) *Call {
+// calls match. Breaks ties between equally good matches with the Mock's
+// tiebreak, or panics if the Mock is strict. The caller must hold the lock.
+//
+// Exhausted calls are not eligible; however, if the best match for the
+// params is exhausted, it is also returned as spent.
+func (m Mock) bestMatch(method string, params ...interface{}) (best *Call, spent *Call) {
 	calls := m[method]
 
-	matches := make([]Call, 0, 3)
+	var matches []*Call
 	bestScore := 0
+	spentScore := 0
 
-	for _, c := range calls {
-		score := c.score(params)
-		if score > 0 && score >= bestScore {
-			matches = append(matches, c)
+	for i := range calls {
+		c := &calls[i]
+		score := c.score(method, params)
+		if score > 0 && c.exhausted() {
+			if score >= spentScore {
+				spent = c
+				spentScore = score
+			}
+		} else if score > bestScore {
+			matches = []*Call{c}
 			bestScore = score
+		} else if score > 0 && score == bestScore {
+			matches = append(matches, c)
 		}
 	}
 
 	switch len(matches) {
 	case 0:
-		return nil
+		return nil, spent
 	case 1:
-		return &matches[0]
+		return matches[0], nil
 	default:
-		var best Call
-		if ChooseCall == nil {
-			best = matches[len(matches)-1]
-		} else {
-			best = ChooseCall(matches)
+		return m.breakTie(method, params, matches), nil
+	}
+}
+
+// Chooses one of several calls that match equally well. The caller must hold
+// the lock.
+func (m Mock) breakTie(method string, params []interface{}, matches []*Call) *Call {
+	s := m.settings()
+	if s.strict {
+		e := &AmbiguousMatchError{Method: method, Params: params}
+		for _, c := range matches {
+			e.Candidates = append(e.Candidates, *c)
+		}
+		panic(e)
+	}
+
+	tiebreak := ChooseCall
+	if s.tiebreak != nil {
+		tiebreak = s.tiebreak
+	}
+	if tiebreak == nil {
+		return matches[len(matches)-1]
+	}
+
+	tied := make([]Call, len(matches))
+	for i, c := range matches {
+		tied[i] = *c
+	}
+	choice := tiebreak(tied)
+	return &choice
+}
+
+// Explain describes how each call allowed for a method compares with the given
+// params, and which of them comes closest to matching. It is meant to explain
+// why Call returned nil; generated test doubles panic with its result:
+//
+//     if _m.Mock.Call("Add", l, r) == nil {
+//       panic(_m.Mock.Explain("Add", l, r))
+//     }
+//
+// The closest call is the exhausted call with the highest score, if any, or
+// else the call whose matchers accepted the most params (the most recently
+// allowed call wins a tie).
+func (m Mock) Explain(method string, params ...interface{}) *UnmatchedCallError {
+	l := m.lock()
+	l.RLock()
+	defer l.RUnlock()
+
+	e := &UnmatchedCallError{Method: method, Params: params, Closest: -1}
+	calls := m[method]
+	best := 0
+	for i := range calls {
+		cand := calls[i].explain(method, params)
+		e.Candidates = append(e.Candidates, cand)
+
+		// Exhausted calls that match outrank any partial match.
+		closeness := cand.Matching
+		if cand.Exhausted && cand.Score > 0 {
+			closeness = len(params) + cand.Score
+		}
+		if closeness > 0 && closeness >= best {
+			best = closeness
+			e.Closest = i
 		}
-		return &best
 	}
+	return e
+}
+
+// Reset forgets every call that was allowed on the Mock, so that it can be
+// reused by another test, and releases any calls that are hanging. Settings
+// such as SetStrict and SetTiebreak are kept.
+func (m Mock) Reset() {
+	m.Release()
+
+	l := m.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	for method := range m {
+		delete(m, method)
+	}
+}
+
+// Unsatisfied returns every required call that has been matched too few or
+// too many times, keyed by method name. Calls appear in the order they were
+// allowed. If every required call is satisfied, it returns an empty map.
+func (m Mock) Unsatisfied() map[string][]Call {
+	l := m.lock()
+	l.RLock()
+	defer l.RUnlock()
+
+	res := map[string][]Call{}
+	for method, calls := range m {
+		for _, c := range calls {
+			if c.unsatisfied() {
+				res[method] = append(res[method], c)
+			}
+		}
+	}
+	return res
+}
+
+// If a test double has an exported field named Delegate, makes it the delegate
+// of the double's Mock. If the double is addressable, the Mock reads the field
+// whenever it needs the delegate, so later assignments to the field are seen;
+// otherwise the field's value is used, unless it is nil. A delegate set with
+// Mock.SetDelegate takes priority over the field.
+func findDelegate(v reflect.Value, mock Mock) {
+	sf, ok := v.Type().FieldByName("Delegate")
+	if !ok || sf.PkgPath != "" {
+		return
+	}
+	f := v.FieldByIndex(sf.Index)
+	if !f.CanAddr() && f.IsZero() {
+		return
+	}
+
+	l := mock.lock()
+	l.Lock()
+	defer l.Unlock()
+	mock.setDelegateField(f)
 }
 
 func isMock(t reflect.Type) bool {
@@ -111,7 +337,7 @@ func FindMock(v reflect.Value) Mock {
 		// The real McCoy! (Or a pointer to it.)
 		if ptr {
 			if v.IsNil() {
-				panic(fmt.Sprintf("gomuti: must initialize %s before calling", v.Type().String()))
+				panic(&UninitializedError{Type: v.Type().String()})
 			}
 			return reflect.Indirect(v).Interface().(Mock)
 		}
@@ -126,29 +352,35 @@ func FindMock(v reflect.Value) Mock {
 				var mock Mock
 				if ptr {
 					if v.IsNil() {
-						panic(fmt.Sprintf("gomuti: must initialize *%s before calling", t.Name()))
+						panic(&UninitializedError{Type: v.Type().String()})
 					}
 					v = reflect.Indirect(v)
 					if !v.IsValid() {
-						panic(fmt.Sprintf("gomuti: must initialize %s.%s before calling", t.Name(), sf.Name))
+						panic(&UninitializedError{Type: t.String(), Field: sf.Name})
 					}
 					f := v.Field(i)
 					if !f.CanInterface() {
-						panic(fmt.Sprintf("gomuti: cannot work with unexported field %s of %s; change it to %s", sf.Name, t.String(), strings.Title(sf.Name)))
+						misuse("FindMock", "cannot work with unexported field %s of %s; change it to %s", sf.Name, t.String(), strings.Title(sf.Name))
 					}
 				}
-				mock = v.Field(i).Interface().(Mock)
-				if mock == nil {
-					if ptr {
+				mock = func() Mock {
+					fields.Lock()
+					defer fields.Unlock()
+					mock := v.Field(i).Interface().(Mock)
+					if mock == nil && ptr {
 						mock = Mock{}
 						reflect.Indirect(v).Field(i).Set(reflect.ValueOf(mock))
-					} else {
-						panic(fmt.Sprintf("gomuti: must pass a pointer to %s or initialize its .Mock before calling", t.String()))
 					}
+					return mock
+				}()
+				if mock == nil {
+					panic(&UninitializedError{Type: t.String(), Field: sf.Name})
 				}
+				findDelegate(reflect.Indirect(v), mock)
 				return mock
 			}
 		}
 	}
-	panic(fmt.Sprintf("gomuti: don't know how to program behaviors for %s", t.String()))
+	misuse("FindMock", "don't know how to program behaviors for %s", t.String())
+	return nil
 }
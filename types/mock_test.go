@@ -1,6 +1,8 @@
 package types_test
 
 import (
+	"errors"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/xeger/gomuti"
@@ -37,4 +39,224 @@ var _ = Describe("Mock", func() {
 			Expect(r2[0]).To(Equal(42))
 		})
 	})
+
+	It("wraps errors of matchers", func() {
+		broken := &types.NumericallyMatcher{Comparator: ">", CompareTo: []interface{}{"x"}}
+		m.Allow().Call("Bar", 1, broken).Return(true)
+
+		var err error
+		func() {
+			defer func() { err, _ = recover().(error) }()
+			m.Call("Bar", 1, 2)
+		}()
+		var me *types.MatcherError
+		Expect(errors.As(err, &me)).To(BeTrue())
+		Expect(me.Method).To(Equal("Bar"))
+		Expect(me.Index).To(Equal(1))
+		Expect(me.Err).To(MatchError(ContainSubstring("not a number")))
+		Expect(err.Error()).To(HavePrefix("gomuti: Bar: matcher for parameter 1 failed: "))
+
+		s := types.Spy{}
+		s.Observe("Bar", 1, 2)
+		Expect(func() { s.Count("Bar", Anything(), broken) }).To(PanicWith(BeAssignableToTypeOf(&types.MatcherError{})))
+	})
+
+	It("explains errors of matchers", func() {
+		broken := &types.NumericallyMatcher{Comparator: ">", CompareTo: []interface{}{"x"}}
+		m.Allow().Call("Bar", broken, broken).Return(true)
+		m.Allow().Call("Bar", 1, 2).Return(false)
+
+		var e *types.UnmatchedCallError
+		Expect(func() { e = m.Explain("Bar", 1, 3) }).NotTo(Panic())
+		c := e.Candidates[0]
+		Expect(c.Score).To(Equal(0))
+		Expect(c.Failed).To(Equal(0))
+		Expect(c.Reason).To(HavePrefix("parameter 0: matcher failed: "))
+		var me *types.MatcherError
+		Expect(errors.As(c.Err, &me)).To(BeTrue())
+		Expect(me.Index).To(Equal(0))
+		Expect(c.Err.Error()).To(ContainSubstring("matcher for parameter 1 failed"))
+
+		Expect(e.Candidates[1].Err).To(BeNil())
+		Expect(e.Candidates[1].Matching).To(Equal(1))
+		Expect(e.Closest).To(Equal(1))
+	})
+
+	Context("given a tiebreak", func() {
+		It("chooses among tied calls only", func() {
+			m.Allow().Call("Bar", Anything()).Return("first")
+			m.Allow().Call("Bar", 1).Return("exact")
+			m.Allow().Call("Bar", Anything()).Return("last")
+
+			var tied [][]types.Call
+			m.SetTiebreak(func(calls []types.Call) types.Call {
+				tied = append(tied, calls)
+				return calls[0]
+			})
+
+			Expect(m.Call("Bar", 1)).To(Equal([]interface{}{"exact"}))
+			Expect(m.Call("Bar", 2)).To(Equal([]interface{}{"first"}))
+			Expect(tied).To(HaveLen(1))
+			Expect(tied[0]).To(HaveLen(2))
+		})
+
+		It("does not affect other mocks", func() {
+			m.SetTiebreak(func(calls []types.Call) types.Call { return calls[0] })
+			other := types.Mock{}
+			other.Allow().Call("Bar").Return(1)
+			other.Allow().Call("Bar").Return(2)
+			Expect(other.Call("Bar")).To(Equal([]interface{}{2}))
+		})
+	})
+
+	Context("when strict", func() {
+		BeforeEach(func() {
+			m.SetStrict(true)
+		})
+
+		It("panics on ambiguous matches", func() {
+			defer func() {
+				e, ok := recover().(*types.AmbiguousMatchError)
+				Expect(ok).To(BeTrue())
+				Expect(e.Method).To(Equal("Foo"))
+				Expect(e.Params).To(Equal([]interface{}{0, 0}))
+				Expect(e.Candidates).To(HaveLen(2))
+				Expect(e.Error()).To(ContainSubstring("2 allowed calls match equally well"))
+			}()
+			m.Call("Foo", 0, 0)
+		})
+
+		It("chooses unambiguous matches", func() {
+			Expect(m.Call("Foo", 42, 42)).To(Equal([]interface{}{42}))
+			Expect(m.Call("Foo", 1, 4)).To(Equal([]interface{}{false}))
+		})
+
+		It("keeps its settings out of the map", func() {
+			Expect(m).To(HaveLen(1))
+			Expect(m.Unsatisfied()).To(BeEmpty())
+
+			copied := types.Mock{}
+			for method, calls := range m {
+				copied[method] = calls
+			}
+			Expect(func() { copied.Call("Foo", 0, 0) }).To(PanicWith("I also hate zero"))
+		})
+	})
+
+	Context("Explain", func() {
+		It("explains every candidate", func() {
+			e := m.Explain("Foo", 1, 2)
+			Expect(e.Method).To(Equal("Foo"))
+			Expect(e.Params).To(Equal([]interface{}{1, 2}))
+			Expect(e.Candidates).To(HaveLen(4))
+			Expect(e.Candidates[0].Score).To(Equal(0))
+			Expect(e.Candidates[0].Failed).To(Equal(0))
+			Expect(e.Candidates[0].Reason).To(ContainSubstring("to equal"))
+			Expect(e.Candidates[2].Reason).To(Equal("matches"))
+			Expect(e.Candidates[3].Matching).To(Equal(0))
+		})
+
+		It("explains mismatched parameter counts", func() {
+			e := m.Explain("Foo", 1)
+			Expect(e.Candidates[3].Failed).To(Equal(-1))
+			Expect(e.Candidates[3].Reason).To(Equal("expected 2 parameters; got 1"))
+		})
+
+		It("suggests the closest candidate", func() {
+			m.Allow().Call("Bar", 1, 2, 3).Return(true)
+			m.Allow().Call("Bar", 1, 5, 5).Return(true)
+			e := m.Explain("Bar", 1, 2, 4)
+			Expect(e.Closest).To(Equal(0))
+			Expect(e.Candidates[0].Matching).To(Equal(2))
+			Expect(e.Candidates[0].Failed).To(Equal(2))
+			Expect(e.Error()).To(ContainSubstring("closest match is #0"))
+		})
+
+		It("prefers exhausted calls", func() {
+			m.Allow().Call("Bar", 1, 2, 3).Return(true)
+			m.Allow().Call("Bar", Anything(), Anything(), Anything()).Once().Return(true)
+			m.Call("Bar", 7, 7, 7)
+			e := m.Explain("Bar", 1, 2, 4)
+			Expect(e.Closest).To(Equal(1))
+			Expect(e.Candidates[1].Exhausted).To(BeTrue())
+			Expect(e.Candidates[1].Reason).To(Equal("exhausted after 1 call"))
+		})
+
+		It("reports methods that were never allowed", func() {
+			e := m.Explain("Baz")
+			Expect(e.Candidates).To(BeEmpty())
+			Expect(e.Closest).To(Equal(-1))
+			Expect(e.Error()).To(Equal("gomuti: no behavior programmed for Baz(); no calls to Baz were allowed"))
+		})
+	})
+
+	Context("given a delegate", func() {
+		It("forwards calls that match no allowed call", func() {
+			m.SetDelegate(calculator{})
+			Expect(m.Call("Foo", 42, 42)).To(Equal([]interface{}{42}))
+			Expect(m.Call("Add", 2, 3)).To(Equal([]interface{}{5}))
+			Expect(m.Call("Sum", []int{1, 2, 3})).To(Equal([]interface{}{6}))
+		})
+
+		It("forwards calls allowed with CallThrough", func() {
+			m.SetDelegate(calculator{})
+			m.Allow().Call("Add").With(1, 1).Return(3)
+			m.Allow().Call("Add").With(2, Anything()).CallThrough()
+			Expect(m.Call("Add", 1, 1)).To(Equal([]interface{}{3}))
+			Expect(m.Call("Add", 2, 2)).To(Equal([]interface{}{4}))
+		})
+
+		It("uses the Delegate field of a test double", func() {
+			double := &partial{}
+			Allow(double).Call("Add").With(1, 1).Return(3)
+			Expect(double.Mock.Call("Add", 2, 2)).To(BeNil())
+
+			double.Delegate = calculator{}
+			Expect(double.Mock.Call("Add", 1, 1)).To(Equal([]interface{}{3}))
+			Expect(double.Mock.Call("Add", 2, 2)).To(Equal([]interface{}{4}))
+		})
+
+		It("prefers a delegate set with SetDelegate to the Delegate field", func() {
+			double := &partial{Mock: types.Mock{}}
+			double.Mock.SetDelegate(calculator{})
+			Allow(double).Call("Foo").Return(1)
+			Expect(double.Mock.Call("Add", 2, 2)).To(Equal([]interface{}{4}))
+
+			double.Mock.SetDelegate(nil)
+			double.Delegate = calculator{}
+			Allow(double).Call("Foo").Return(1)
+			Expect(double.Mock.Call("Add", 2, 2)).To(BeNil())
+		})
+
+		It("panics when the delegate lacks the method", func() {
+			m.SetDelegate(calculator{})
+			Expect(func() {
+				m.Call("Subtract", 2, 1)
+			}).To(PanicWith(MatchError("gomuti: cannot call Subtract through to types_test.calculator, which has no such method")))
+		})
+
+		It("panics on CallThrough without a delegate", func() {
+			m.Allow().Call("Add").CallThrough()
+			Expect(func() {
+				m.Call("Add", 1, 1)
+			}).To(PanicWith(BeAssignableToTypeOf(&types.DSLMisuseError{})))
+		})
+	})
 })
+
+type calculator struct{}
+
+func (calculator) Add(l, r int) int { return l + r }
+
+func (calculator) Sum(ns ...int) int {
+	total := 0
+	for _, n := range ns {
+		total += n
+	}
+	return total
+}
+
+type partial struct {
+	Mock     types.Mock
+	Delegate interface{ Add(l, r int) int }
+}
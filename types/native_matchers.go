@@ -0,0 +1,373 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Gomuti's own parameter matchers, which work without Gomega. MatchParams
+// uses EqualMatcher, EquivalentMatcher and NilMatcher for literal params;
+// package gomuti/matchers has functions that create all of them. Each matcher
+// has a FailureMessage, which Mock.Explain uses to describe a mismatch.
+//
+// Matchers never return an error for a param of the wrong type; the param
+// simply does not match.
+
+// EqualMatcher matches values that are deeply equal to Expected, in the sense
+// of reflect.DeepEqual. Numbers of different sizes are equal if they have the
+// same kind and value (see widen); e.g. int8(1) equals int64(1).
+type EqualMatcher struct {
+	Expected interface{}
+}
+
+// Match returns true if actual is deeply equal to the expected value.
+func (m *EqualMatcher) Match(actual interface{}) (bool, error) {
+	return reflect.DeepEqual(widen(actual), widen(m.Expected)), nil
+}
+
+// FailureMessage returns a description of why the matcher did not match.
+func (m *EqualMatcher) FailureMessage(actual interface{}) string {
+	return FormatMessage(actual, "to equal", m.Expected)
+}
+
+// Weight returns 4, the highest weight of any matcher.
+func (m *EqualMatcher) Weight() int {
+	return 4
+}
+
+// EquivalentMatcher matches values that are deeply equal to Expected after
+// they have been converted to Expected's type, if possible; for instance,
+// the int32 5 is equivalent to the int64 5.
+type EquivalentMatcher struct {
+	Expected interface{}
+}
+
+// Match returns true if actual is equivalent to the expected value.
+func (m *EquivalentMatcher) Match(actual interface{}) (bool, error) {
+	if actual == nil || m.Expected == nil {
+		return actual == nil && m.Expected == nil, nil
+	}
+	v, t := reflect.ValueOf(actual), reflect.TypeOf(m.Expected)
+	switch {
+	case !v.Type().ConvertibleTo(t):
+	case v.Kind() == reflect.Slice && t.Kind() != reflect.Slice:
+		// converting a slice to an array panics if the slice is too short
+	case numericKind(v.Kind()) != reflect.Invalid && t.Kind() == reflect.String:
+		// converting a number to a string yields a rune, not its digits
+	default:
+		actual = v.Convert(t).Interface()
+	}
+	return reflect.DeepEqual(actual, m.Expected), nil
+}
+
+// FailureMessage returns a description of why the matcher did not match.
+func (m *EquivalentMatcher) FailureMessage(actual interface{}) string {
+	return FormatMessage(actual, "to be equivalent to", m.Expected)
+}
+
+// Weight returns 3, which ranks it below EqualMatcher.
+func (m *EquivalentMatcher) Weight() int {
+	return 3
+}
+
+// NilMatcher matches nil, and nil values of nillable types such as pointers
+// and slices.
+type NilMatcher struct{}
+
+// Match returns true if actual is nil.
+func (m *NilMatcher) Match(actual interface{}) (bool, error) {
+	if actual == nil {
+		return true, nil
+	}
+	v := reflect.ValueOf(actual)
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice, reflect.UnsafePointer:
+		return v.IsNil(), nil
+	}
+	return false, nil
+}
+
+// FailureMessage returns a description of why the matcher did not match.
+func (m *NilMatcher) FailureMessage(actual interface{}) string {
+	return FormatMessage(actual, "to be nil")
+}
+
+// Weight returns 4, the same as EqualMatcher.
+func (m *NilMatcher) Weight() int {
+	return 4
+}
+
+// AnyMatcher matches any value, including nil.
+type AnyMatcher struct{}
+
+// Match always returns true.
+func (m *AnyMatcher) Match(actual interface{}) (bool, error) {
+	return true, nil
+}
+
+// FailureMessage returns a description of why the matcher did not match.
+func (m *AnyMatcher) FailureMessage(actual interface{}) string {
+	return FormatMessage(actual, "to be any value")
+}
+
+// TypeOfMatcher matches non-nil values that are assignable to Expected, which
+// may be an interface type. Unlike most matchers, it sees numeric params as
+// they were passed, rather than widened to 64 bits.
+type TypeOfMatcher struct {
+	Expected reflect.Type
+}
+
+// Match returns true if actual can be assigned to the expected type.
+func (m *TypeOfMatcher) Match(actual interface{}) (bool, error) {
+	return actual != nil && reflect.TypeOf(actual).AssignableTo(m.Expected), nil
+}
+
+// FailureMessage returns a description of why the matcher did not match.
+func (m *TypeOfMatcher) FailureMessage(actual interface{}) string {
+	return FormatMessage(actual, "to be of type "+m.Expected.String())
+}
+
+// AndMatcher matches values that satisfy all of its Matchers.
+type AndMatcher struct {
+	Matchers []Matcher
+}
+
+// Match returns true if every matcher matches actual.
+func (m *AndMatcher) Match(actual interface{}) (bool, error) {
+	for _, sub := range m.Matchers {
+		if ok, err := sub.Match(actual); err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// FailureMessage describes the first matcher that did not match.
+func (m *AndMatcher) FailureMessage(actual interface{}) string {
+	for _, sub := range m.Matchers {
+		if ok, _ := sub.Match(actual); !ok {
+			return describeFailure(sub, actual)
+		}
+	}
+	return FormatMessage(actual, "to match all of", m.Matchers)
+}
+
+// OrMatcher matches values that satisfy at least one of its Matchers.
+type OrMatcher struct {
+	Matchers []Matcher
+}
+
+// Match returns true if any matcher matches actual.
+func (m *OrMatcher) Match(actual interface{}) (bool, error) {
+	for _, sub := range m.Matchers {
+		ok, err := sub.Match(actual)
+		if err != nil {
+			return false, err
+		} else if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FailureMessage returns a description of why the matcher did not match.
+func (m *OrMatcher) FailureMessage(actual interface{}) string {
+	return FormatMessage(actual, "to match one of", m.Matchers)
+}
+
+// NotMatcher matches values that its Matcher does not match.
+type NotMatcher struct {
+	Matcher Matcher
+}
+
+// Match returns true if the matcher does not match actual.
+func (m *NotMatcher) Match(actual interface{}) (bool, error) {
+	ok, err := m.Matcher.Match(actual)
+	return !ok && err == nil, err
+}
+
+// FailureMessage returns a description of why the matcher did not match.
+func (m *NotMatcher) FailureMessage(actual interface{}) string {
+	return FormatMessage(actual, "not to match", m.Matcher)
+}
+
+// RegexpMatcher matches strings, byte slices and fmt.Stringers that contain a
+// match for a regular expression.
+type RegexpMatcher struct {
+	Expected *regexp.Regexp
+}
+
+// Match returns true if actual is a string that matches the expression.
+func (m *RegexpMatcher) Match(actual interface{}) (bool, error) {
+	switch a := actual.(type) {
+	case string:
+		return m.Expected.MatchString(a), nil
+	case []byte:
+		return m.Expected.Match(a), nil
+	case fmt.Stringer:
+		return m.Expected.MatchString(a.String()), nil
+	}
+	return false, nil
+}
+
+// FailureMessage returns a description of why the matcher did not match.
+func (m *RegexpMatcher) FailureMessage(actual interface{}) string {
+	return FormatMessage(actual, "to match regular expression", m.Expected.String())
+}
+
+// NumericallyMatcher compares numbers of any type with CompareTo, using one of
+// these comparators: "==", "!=", "<", "<=", ">", ">=" or "~". The last means
+// "approximately equal"; CompareTo may hold a second number, the greatest
+// difference allowed, which defaults to 1e-8.
+type NumericallyMatcher struct {
+	Comparator string
+	CompareTo  []interface{}
+}
+
+// Match returns true if actual is a number that compares as specified.
+func (m *NumericallyMatcher) Match(actual interface{}) (bool, error) {
+	if len(m.CompareTo) == 0 {
+		return false, fmt.Errorf("Numerically(%q) needs a number to compare to", m.Comparator)
+	}
+	a, ok := toNumber(actual)
+	e, eok := toNumber(m.CompareTo[0])
+	if !eok {
+		return false, fmt.Errorf("Numerically(%q) cannot compare to %#v, which is not a number", m.Comparator, m.CompareTo[0])
+	} else if !ok {
+		return false, nil
+	}
+
+	if m.Comparator == "~" {
+		threshold := 1e-8
+		if len(m.CompareTo) > 1 {
+			t, ok := toNumber(m.CompareTo[1])
+			if !ok {
+				return false, fmt.Errorf("Numerically(\"~\") cannot use %#v as a threshold", m.CompareTo[1])
+			}
+			threshold = t.float()
+		}
+		return math.Abs(a.float()-e.float()) <= threshold, nil
+	}
+
+	c := a.compare(e)
+	switch m.Comparator {
+	case "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	}
+	return false, fmt.Errorf("Numerically() does not understand the comparator %q", m.Comparator)
+}
+
+// FailureMessage returns a description of why the matcher did not match.
+func (m *NumericallyMatcher) FailureMessage(actual interface{}) string {
+	return FormatMessage(actual, fmt.Sprintf("to be %s", m.Comparator), m.CompareTo...)
+}
+
+// A number of any type, kept as precisely as its kind allows.
+type number struct {
+	kind reflect.Kind // Int, Uint or Float64; see numericKind
+	i    int64
+	u    uint64
+	f    float64
+}
+
+func toNumber(v interface{}) (number, bool) {
+	if v == nil {
+		return number{}, false
+	}
+	rv := reflect.ValueOf(v)
+	n := number{kind: numericKind(rv.Kind())}
+	switch n.kind {
+	case reflect.Int:
+		n.i = rv.Int()
+	case reflect.Uint:
+		n.u = rv.Uint()
+	case reflect.Float64:
+		n.f = rv.Float()
+	default:
+		return number{}, false
+	}
+	return n, true
+}
+
+func (n number) float() float64 {
+	switch n.kind {
+	case reflect.Int:
+		return float64(n.i)
+	case reflect.Uint:
+		return float64(n.u)
+	}
+	return n.f
+}
+
+// Returns -1, 0 or 1 as n is less than, equal to or greater than o. Integers
+// are compared exactly; anything involving a float is compared as floats.
+func (n number) compare(o number) int {
+	switch {
+	case n.kind == reflect.Int && o.kind == reflect.Int:
+		return sign(n.i > o.i, n.i < o.i)
+	case n.kind == reflect.Uint && o.kind == reflect.Uint:
+		return sign(n.u > o.u, n.u < o.u)
+	case n.kind == reflect.Int && o.kind == reflect.Uint:
+		if n.i < 0 {
+			return -1
+		}
+		return number{kind: reflect.Uint, u: uint64(n.i)}.compare(o)
+	case n.kind == reflect.Uint && o.kind == reflect.Int:
+		return -o.compare(n)
+	}
+	return sign(n.float() > o.float(), n.float() < o.float())
+}
+
+func sign(greater, less bool) int {
+	if greater {
+		return 1
+	} else if less {
+		return -1
+	}
+	return 0
+}
+
+// FormatMessage describes a failed match in the same way as Gomega's
+// format.Message, so that matchers can explain themselves without depending
+// on Gomega:
+//
+//     Expected
+//         <int>: 5
+//     to equal
+//         <int>: 6
+func FormatMessage(actual interface{}, message string, expected ...interface{}) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "Expected\n%s\n%s", formatObject(actual), message)
+	for _, e := range expected {
+		fmt.Fprintf(b, "\n%s", formatObject(e))
+	}
+	return b.String()
+}
+
+func formatObject(v interface{}) string {
+	if v == nil {
+		return "    <nil>: nil"
+	}
+	return fmt.Sprintf("    <%T>: %s", v, indent(fmt.Sprintf("%#v", v), "    "))
+}
+
+// Describes why a matcher did not match, whether or not it can explain itself.
+func describeFailure(m Matcher, actual interface{}) string {
+	if fm, ok := m.(failureMessager); ok {
+		return fm.FailureMessage(actual)
+	}
+	return FormatMessage(actual, "to match", m)
+}
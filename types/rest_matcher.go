@@ -0,0 +1,116 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// RestMatcher matches all of the remaining parameters of a method call,
+// typically the values passed to a variadic parameter. It may only appear as
+// the final parameter matcher of a call; create one with gomuti.Rest or
+// gomuti.VariadicWith.
+//
+// A RestMatcher in position N sees the params from position N onwards as a
+// single []interface{}. Generated test doubles pass a variadic parameter as
+// one slice; if exactly one param remains and it is a slice, the RestMatcher
+// sees the elements of that slice instead. Thus both of these calls
+//
+//     double.Log("info", "%d-%s", 1, "a")          // hand-written double
+//     double.Mock.Call("Log", "info", "%d-%s", []interface{}{1, "a"})
+//
+// are matched by With("info", "%d-%s", VariadicWith(1, "a")).
+type RestMatcher struct {
+	// Matches the remaining params as a whole, if not nil.
+	Matcher Matcher
+	// Otherwise, matches each of the remaining params in turn; there must be
+	// exactly as many params as matchers.
+	Each []Matcher
+}
+
+// Match succeeds if actual, which should be a slice holding the remaining
+// params of a method call, satisfies the matcher.
+func (m *RestMatcher) Match(actual interface{}) (bool, error) {
+	rest := spread(actual)
+	if m.Matcher != nil {
+		return m.Matcher.Match(rest)
+	}
+	if len(rest) != len(m.Each) {
+		return false, nil
+	}
+	for i, p := range rest {
+		succ, err := matchParam(m.Each[i], p)
+		if err != nil || !succ {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// FailureMessage returns a description of why the matcher did not match.
+func (m *RestMatcher) FailureMessage(actual interface{}) string {
+	rest := spread(actual)
+	if m.Matcher != nil {
+		return describeFailure(m.Matcher, rest)
+	}
+	if len(rest) != len(m.Each) {
+		return fmt.Sprintf("expected %d remaining %s; got %d",
+			len(m.Each), plural(len(m.Each), "parameter", "parameters"), len(rest))
+	}
+	return FormatMessage(rest, "to match", m.Each)
+}
+
+// Weight scores the matcher as though its matchers had been passed to With
+// directly.
+func (m *RestMatcher) Weight() int {
+	if m.Matcher != nil {
+		return weight(m.Matcher)
+	}
+	w := 0
+	for _, e := range m.Each {
+		w += weight(e)
+	}
+	if w == 0 {
+		return 1
+	}
+	return w
+}
+
+// Gathers the params of a method call to line up with a sequence of matchers.
+// If the final matcher is a RestMatcher, the params at and after its position
+// are replaced with one []interface{} that holds them; otherwise, or if there
+// are too few params, the params are returned as they are.
+func gatherRest(matchers []Matcher, params []interface{}) []interface{} {
+	n := len(matchers) - 1
+	if n < 0 || len(params) < n {
+		return params
+	}
+	if _, ok := matchers[n].(*RestMatcher); !ok {
+		return params
+	}
+
+	rest := params[n:]
+	if len(rest) == 1 && rest[0] != nil && reflect.TypeOf(rest[0]).Kind() == reflect.Slice {
+		rest = spread(rest[0])
+	}
+	res := make([]interface{}, n+1)
+	copy(res, params[:n])
+	res[n] = rest
+	return res
+}
+
+// Converts a slice of any type into a []interface{}; wraps anything else in
+// a slice of one element.
+func spread(v interface{}) []interface{} {
+	if s, ok := v.([]interface{}); ok {
+		return s
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return []interface{}{v}
+	}
+	res := make([]interface{}, rv.Len())
+	for i := range res {
+		res[i] = rv.Index(i).Interface()
+	}
+	return res
+}
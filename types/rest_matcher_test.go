@@ -0,0 +1,85 @@
+package types_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/xeger/gomuti"
+	"github.com/xeger/gomuti/types"
+)
+
+var _ = Describe("RestMatcher", func() {
+	var m types.Mock
+
+	BeforeEach(func() {
+		m = types.Mock{}
+	})
+
+	Context("with Allow", func() {
+		It("matches variadic params passed one by one", func() {
+			Â(m).Call("Log").With("info", "%d-%s", VariadicWith(1, "a")).Return(true)
+			Expect(m.Call("Log", "info", "%d-%s", 1, "a")).To(Equal([]interface{}{true}))
+			Expect(m.Call("Log", "info", "%d-%s", 1)).To(BeNil())
+			Expect(m.Call("Log", "info", "%d-%s", 1, "a", "b")).To(BeNil())
+		})
+
+		It("matches variadic params passed as a slice", func() {
+			Â(m).Call("Log").With("info", "%d-%s", VariadicWith(1, "a")).Return(true)
+			Expect(m.Call("Log", "info", "%d-%s", []interface{}{1, "a"})).To(Equal([]interface{}{true}))
+		})
+
+		It("matches no variadic params", func() {
+			Â(m).Call("Log").With("info", "hello", Rest(BeEmpty())).Return(true)
+			Expect(m.Call("Log", "info", "hello")).To(Equal([]interface{}{true}))
+			Expect(m.Call("Log", "info", "hello", []interface{}(nil))).To(Equal([]interface{}{true}))
+			Expect(m.Call("Log", "info")).To(BeNil())
+		})
+
+		It("matches the remaining params as a whole", func() {
+			Â(m).Call("Log").With("info", Rest(ContainElement("user"))).Return(true)
+			Expect(m.Call("Log", "info", "%s: %s", "user", "alice")).To(Equal([]interface{}{true}))
+			Expect(m.Call("Log", "info", "%s", "nobody")).To(BeNil())
+		})
+
+		It("widens numbers, like With", func() {
+			Â(m).Call("Sum").With(VariadicWith(int64(1), 2)).Return(3)
+			Expect(m.Call("Sum", int32(1), 2)).To(Equal([]interface{}{3}))
+		})
+
+		It("prefers the more specific matcher", func() {
+			Â(m).Call("Log").With("info", Anything(), VariadicWith(42)).Return("specific")
+			Â(m).Call("Log").With("info", Anything(), Rest(Anything())).Return("general")
+			Expect(m.Call("Log", "info", "%d", 42)).To(Equal([]interface{}{"specific"}))
+			Expect(m.Call("Log", "info", "%d", 7)).To(Equal([]interface{}{"general"}))
+		})
+
+		It("explains mismatches", func() {
+			Â(m).Call("Log").With("info", "%s", VariadicWith("alice"))
+			Expect(m.Explain("Log").Error()).To(ContainSubstring("expected at least 2 parameters; got 0"))
+			Expect(m.Explain("Log", "info", "%s", "bob").Error()).To(ContainSubstring("parameter 2:"))
+		})
+	})
+
+	Context("with HaveCall", func() {
+		It("matches the same params as a Mock", func() {
+			s := types.Spy{}
+			s.Observe("Log", "info", "%d-%s", 1, "a")
+			s.Observe("Log", "info", "%d-%s", []interface{}{2, "b"})
+			s.Observe("Log", "debug", "hello")
+
+			Expect(s).To(HaveCall("Log").With("info", Anything(), VariadicWith(Anything(), BeAssignableToTypeOf(""))).Twice())
+			Expect(s).To(HaveCall("Log").With("info", Anything(), VariadicWith(2, "b")).Once())
+			Expect(s).To(HaveCall("Log").With("info", Rest(HaveLen(3))).Once())
+			Expect(s).To(HaveCall("Log").With("debug", "hello", Rest(BeEmpty())).Once())
+			Expect(s).NotTo(HaveCall("Log").With("debug", "hello", VariadicWith(Anything())))
+		})
+
+		It("finds the closest match", func() {
+			s := types.Spy{}
+			s.Observe("Log", "debug", "%s", "x")
+			s.Observe("Log", "debug", "%s", "a", "b")
+
+			Expect(s.ClosestMatch("Log", types.MatchParams([]interface{}{"info", Anything(), VariadicWith("a", "b")})...)).
+				To(Equal([]interface{}{"debug", "%s", "a", "b"}))
+		})
+	})
+})
@@ -0,0 +1,243 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SetArg causes the mock to write a value through one of the params of a
+// method call before the call behaves as programmed with Return, Panic or Do.
+// It mocks methods that fill a value provided by their caller:
+//
+//     Â(double, "Decode").SetArg(0, User{Name: "Ann"}).Return(nil)
+//     Â(double, "Scan").SetArg(0, 42).SetArg(1, "answer").Return(nil)
+//
+// How the value is written depends upon the param at index i:
+//   - through a pointer, the value is stored in the pointed-to variable;
+//   - into a slice, the value must be a slice or array whose elements are
+//     copied to the start of the param, which must be long enough to hold
+//     them all (use CopyInto to copy only what fits);
+//   - into a map, the value must be a map whose entries are added to the
+//     param.
+//
+// Numbers are converted to the param's numeric type if they fit, as with Do.
+//
+// Generated test doubles pass variadic params to the Mock as one slice. If
+// Gomuti knows the signature of the method (see Mock.AllowFor), the index of
+// the variadic param and those after it refer to the elements of that slice,
+// so that the 2nd destination of Scan(dest ...interface{}) is index 1.
+//
+// If Gomuti knows the signature of the method, SetArg checks the value against
+// the type of the param and panics with a SetArgError if it cannot be
+// written. Otherwise, the mock panics with a SetArgError when it is called.
+//
+// Like Return, SetArg applies to the current behavior; after Then, you may
+// write different values.
+func (a *Allowed) SetArg(i int, value interface{}) *Allowed {
+	return a.write("SetArg", argWrite{index: i, value: value})
+}
+
+// CopyInto is like SetArg, but copies the elements of a slice (or the bytes
+// of a string) into a slice param as the built-in copy function does, which
+// is to say only as many as fit. It mocks methods that fill a buffer:
+//
+//     Â(double, "Read").CopyInto(0, []byte("hello")).Return(5, nil)
+func (a *Allowed) CopyInto(i int, elems interface{}) *Allowed {
+	return a.write("CopyInto", argWrite{index: i, value: elems, copy: true})
+}
+
+func (a *Allowed) write(verb string, w argWrite) *Allowed {
+	if w.index < 0 {
+		misuse(verb, "parameter index must not be negative; got %d", w.index)
+	}
+	w.variadic = -1
+	if a.sig != nil {
+		if a.sig.IsVariadic() {
+			w.variadic = a.sig.NumIn() - 1
+		}
+		w.check(a.last, a.sig)
+	}
+
+	l := a.mock.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	call := a.outcome(verb)
+	call.writes = append(call.writes, w)
+	return a
+}
+
+// A value to write through a param of a method call; see Allowed.SetArg and
+// Allowed.CopyInto.
+type argWrite struct {
+	index int
+	value interface{}
+	// true for CopyInto
+	copy bool
+	// position of the method's variadic param, or -1 if the method has none
+	// or its signature is unknown
+	variadic int
+}
+
+// Panics with a SetArgError unless the write fits a param of the given
+// method signature. Params of interface type are not checked, since only the
+// actual param can tell.
+func (w argWrite) check(method string, sig reflect.Type) {
+	n := sig.NumIn()
+	var t reflect.Type
+	switch {
+	case w.variadic >= 0 && w.index >= w.variadic:
+		t = sig.In(w.variadic).Elem()
+	case w.index < n:
+		t = sig.In(w.index)
+	default:
+		panic(&SetArgError{Method: method, Index: w.index, Value: w.value,
+			Reason: fmt.Sprintf("the method has only %d %s", n, plural(n, "parameter", "parameters"))})
+	}
+
+	// Write into a fresh value of the param's type.
+	var dst reflect.Value
+	switch t.Kind() {
+	case reflect.Interface:
+		return
+	case reflect.Ptr:
+		dst = reflect.New(t.Elem())
+	case reflect.Slice:
+		n := 0
+		if v := reflect.ValueOf(w.value); v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			n = v.Len()
+		}
+		dst = reflect.MakeSlice(t, n, n)
+	case reflect.Map:
+		dst = reflect.MakeMap(t)
+	default:
+		dst = reflect.Zero(t)
+	}
+	if reason := w.assign(dst); reason != "" {
+		panic(&SetArgError{Method: method, Index: w.index, Param: t, Value: w.value, Reason: reason})
+	}
+}
+
+// Writes through the params of a method call, or panics with a SetArgError.
+func (w argWrite) apply(method string, params []interface{}) {
+	p, n, ok := w.target(params)
+	e := &SetArgError{Method: method, Index: w.index, Value: w.value}
+	if !ok {
+		e.Reason = fmt.Sprintf("the call has only %d %s", n, plural(n, "parameter", "parameters"))
+		panic(e)
+	} else if p == nil {
+		e.Reason = "the parameter is nil"
+		panic(e)
+	}
+	dst := reflect.ValueOf(p)
+	if reason := w.assign(dst); reason != "" {
+		e.Param, e.Reason = dst.Type(), reason
+		panic(e)
+	}
+}
+
+// Returns the param of a method call to write through, looking inside the
+// slice of variadic params that generated test doubles pass, and the number
+// of params counted that way; reports whether there was a param to return.
+func (w argWrite) target(params []interface{}) (interface{}, int, bool) {
+	if v := w.variadic; v >= 0 && len(params) == v+1 {
+		if rest := reflect.ValueOf(params[v]); rest.Kind() == reflect.Slice {
+			n := v + rest.Len()
+			if w.index >= n {
+				return nil, n, false
+			} else if w.index >= v {
+				return rest.Index(w.index - v).Interface(), n, true
+			}
+			return params[w.index], n, true
+		}
+	}
+	if w.index < len(params) {
+		return params[w.index], len(params), true
+	}
+	return nil, len(params), false
+}
+
+// Writes the value through dst; returns the reason if that is impossible, in
+// which case nothing is written.
+func (w argWrite) assign(dst reflect.Value) string {
+	src := reflect.ValueOf(w.value)
+	if w.copy {
+		if dst.Kind() != reflect.Slice {
+			return fmt.Sprintf("CopyInto can only copy into a slice, not %s", dst.Type())
+		}
+		elem := dst.Type().Elem()
+		switch {
+		case src.Kind() == reflect.String && elem.Kind() == reflect.Uint8:
+			reflect.Copy(dst, src)
+		case (src.Kind() == reflect.Slice || src.Kind() == reflect.Array) && src.Type().Elem().AssignableTo(elem):
+			for i := 0; i < src.Len() && i < dst.Len(); i++ {
+				dst.Index(i).Set(src.Index(i))
+			}
+		default:
+			return fmt.Sprintf("cannot copy %s into %s", typeName(w.value), dst.Type())
+		}
+		return ""
+	}
+
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if dst.IsNil() {
+			return "the pointer is nil"
+		}
+		v, ok := convertParam(w.value, dst.Type().Elem())
+		if !ok {
+			return fmt.Sprintf("%s cannot be assigned to %s", typeName(w.value), dst.Type().Elem())
+		}
+		dst.Elem().Set(v)
+	case reflect.Slice:
+		if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+			return fmt.Sprintf("SetArg needs a slice or array to copy into %s; got %s", dst.Type(), typeName(w.value))
+		} else if src.Len() > dst.Len() {
+			return fmt.Sprintf("%d elements do not fit in a slice of length %d; use CopyInto to copy only what fits", src.Len(), dst.Len())
+		}
+		elems := make([]reflect.Value, src.Len())
+		for i := range elems {
+			v, ok := convertParam(src.Index(i).Interface(), dst.Type().Elem())
+			if !ok {
+				return fmt.Sprintf("element %d is %s, which cannot be assigned to %s", i, typeName(src.Index(i).Interface()), dst.Type().Elem())
+			}
+			elems[i] = v
+		}
+		for i, v := range elems {
+			dst.Index(i).Set(v)
+		}
+	case reflect.Map:
+		if dst.IsNil() {
+			return "the map is nil"
+		} else if src.Kind() != reflect.Map {
+			return fmt.Sprintf("SetArg needs a map to add to %s; got %s", dst.Type(), typeName(w.value))
+		}
+		t := dst.Type()
+		var keys, vals []reflect.Value
+		for it := src.MapRange(); it.Next(); {
+			k, ok := convertParam(it.Key().Interface(), t.Key())
+			if !ok {
+				return fmt.Sprintf("key %#v cannot be assigned to %s", it.Key().Interface(), t.Key())
+			}
+			v, ok := convertParam(it.Value().Interface(), t.Elem())
+			if !ok {
+				return fmt.Sprintf("value %#v cannot be assigned to %s", it.Value().Interface(), t.Elem())
+			}
+			keys, vals = append(keys, k), append(vals, v)
+		}
+		for i, k := range keys {
+			dst.SetMapIndex(k, vals[i])
+		}
+	default:
+		return fmt.Sprintf("SetArg can only write through a pointer, slice or map, not %s", dst.Type())
+	}
+	return ""
+}
+
+// Names the type of a value, which may be nil.
+func typeName(v interface{}) string {
+	if v == nil {
+		return "nil"
+	}
+	return reflect.TypeOf(v).String()
+}
@@ -0,0 +1,137 @@
+package types_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/xeger/gomuti"
+	"github.com/xeger/gomuti/types"
+)
+
+// A hand-written test double whose methods fill values provided by their
+// caller. Like generated doubles, it passes variadic params as one slice.
+type filler struct {
+	Mock types.Mock
+}
+
+func (f *filler) Read(p []byte) (int, error) {
+	res := f.Mock.Call("Read", p)
+	n, _ := res[0].(int)
+	err, _ := res[1].(error)
+	return n, err
+}
+
+func (f *filler) Scan(dest ...interface{}) error {
+	res := f.Mock.Call("Scan", dest)
+	err, _ := res[0].(error)
+	return err
+}
+
+func (f *filler) Count(n *int64) {
+	f.Mock.Call("Count", n)
+}
+
+func (f *filler) Tags(m map[string]int) {
+	f.Mock.Call("Tags", m)
+}
+
+var _ = Describe("Allowed", func() {
+	var double *filler
+
+	BeforeEach(func() {
+		double = &filler{}
+	})
+
+	Context("SetArg", func() {
+		It("writes through pointers", func() {
+			Allow(double).Call("Count").SetArg(0, 7)
+			var n int64
+			double.Count(&n)
+			Expect(n).To(Equal(int64(7)))
+		})
+
+		It("writes into the elements of variadic params", func() {
+			Allow(double).Call("Scan").SetArg(0, 42).SetArg(1, "answer").Return(nil)
+			var n int
+			var s string
+			Expect(double.Scan(&n, &s)).To(Succeed())
+			Expect(n).To(Equal(42))
+			Expect(s).To(Equal("answer"))
+		})
+
+		It("copies into slices that are long enough", func() {
+			Allow(double).Call("Read").SetArg(0, []byte("hi")).Return(2, nil)
+			p := make([]byte, 4)
+			Expect(double.Read(p)).To(Equal(2))
+			Expect(p).To(Equal([]byte{'h', 'i', 0, 0}))
+
+			Expect(func() {
+				double.Read(make([]byte, 1))
+			}).To(PanicWith(BeAssignableToTypeOf(&types.SetArgError{})))
+		})
+
+		It("adds entries to maps", func() {
+			Allow(double).Call("Tags").SetArg(0, map[string]int{"a": 1})
+			m := map[string]int{"b": 2}
+			double.Tags(m)
+			Expect(m).To(Equal(map[string]int{"a": 1, "b": 2}))
+		})
+
+		It("applies to one behavior in a sequence", func() {
+			Allow(double).Call("Count").SetArg(0, 1).Then().SetArg(0, 2)
+			var n int64
+			double.Count(&n)
+			Expect(n).To(Equal(int64(1)))
+			double.Count(&n)
+			Expect(n).To(Equal(int64(2)))
+		})
+
+		It("checks values against the method's signature", func() {
+			Expect(func() {
+				Allow(double).Call("Count").SetArg(0, "seven")
+			}).To(PanicWith(MatchError(`gomuti: Count: cannot set parameter 0 (*int64): string cannot be assigned to int64`)))
+			Expect(func() {
+				Allow(double).Call("Count").SetArg(1, 7)
+			}).To(PanicWith(MatchError(`gomuti: Count: cannot set parameter 1: the method has only 1 parameter`)))
+			Expect(func() {
+				Allow(double).Call("Tags").SetArg(0, []int{1})
+			}).To(PanicWith(BeAssignableToTypeOf(&types.SetArgError{})))
+		})
+
+		It("checks values against the params of the call", func() {
+			Allow(double).Call("Scan").SetArg(0, "x")
+			var n int
+			Expect(func() {
+				double.Scan(&n)
+			}).To(PanicWith(MatchError(`gomuti: Scan: cannot set parameter 0 (*int): string cannot be assigned to int`)))
+			Expect(func() {
+				double.Scan(nil)
+			}).To(PanicWith(MatchError(`gomuti: Scan: cannot set parameter 0: the parameter is nil`)))
+			Expect(func() {
+				double.Scan()
+			}).To(PanicWith(MatchError(`gomuti: Scan: cannot set parameter 0: the call has only 0 parameters`)))
+		})
+
+		It("works without a signature", func() {
+			m := types.Mock{}
+			Â(m).Call("Get").SetArg(1, 3.5)
+			var f float32
+			m.Call("Get", "key", &f)
+			Expect(f).To(Equal(float32(3.5)))
+		})
+	})
+
+	Context("CopyInto", func() {
+		It("copies only what fits", func() {
+			Allow(double).Call("Read").CopyInto(0, "hello").Return(3, nil)
+			p := make([]byte, 3)
+			Expect(double.Read(p)).To(Equal(3))
+			Expect(string(p)).To(Equal("hel"))
+		})
+
+		It("rejects mismatched element types", func() {
+			Expect(func() {
+				Allow(double).Call("Read").CopyInto(0, []int{1})
+			}).To(PanicWith(MatchError(`gomuti: Read: cannot set parameter 0 ([]uint8): cannot copy []int into []uint8`)))
+		})
+	})
+})
@@ -0,0 +1,175 @@
+package types
+
+import (
+	"reflect"
+	"unsafe"
+	"weak"
+)
+
+// Per-Mock settings; see Mock.SetStrict, Mock.SetTiebreak, Mock.SetDelegate
+// and Mock.SetClock. They are kept in the Mock's state, not in the Mock
+// itself, and survive Reset.
+type settings struct {
+	strict   bool
+	tiebreak func([]Call) Call
+	// the delegate; see also field
+	delegate reflect.Value
+	// the Delegate field of a test double, which is read whenever the delegate
+	// is needed; or nil. The state of a Mock must not refer to the double
+	// that contains the Mock, so the field is only pointed to weakly.
+	field     weak.Pointer[byte]
+	fieldType reflect.Type
+	// true once SetDelegate has been called, after which the Delegate field
+	// is ignored
+	explicit bool
+	clock    Clock
+	// closed to release hanging calls; see Mock.Release
+	release chan struct{}
+}
+
+// Returns the settings of a Mock. The caller must hold the lock.
+func (m Mock) settings() *settings {
+	return &stateOf(m).settings
+}
+
+// SetStrict enables or disables strict matching. When several allowed calls
+// match a method call equally well, a strict Mock panics with an
+// AmbiguousMatchError instead of breaking the tie.
+func (m Mock) SetStrict(strict bool) {
+	if m == nil {
+		panic(&UninitializedError{Type: "types.Mock", Method: "SetStrict"})
+	}
+
+	l := m.lock()
+	l.Lock()
+	defer l.Unlock()
+	m.settings().strict = strict
+}
+
+// SetTiebreak specifies how the Mock chooses between several allowed calls
+// that match a method call equally well. The tiebreak receives the tied calls
+// in the order they were allowed. If it is nil, the Mock chooses the most
+// recently allowed call.
+//
+// The tiebreak is called while the Mock is locked, so it must not call the
+// test double that the Mock belongs to.
+func (m Mock) SetTiebreak(tiebreak func([]Call) Call) {
+	if m == nil {
+		panic(&UninitializedError{Type: "types.Mock", Method: "SetTiebreak"})
+	}
+
+	l := m.lock()
+	l.Lock()
+	defer l.Unlock()
+	m.settings().tiebreak = tiebreak
+}
+
+// SetDelegate specifies a real implementation of the mocked interface. The
+// Mock forwards method calls that match no allowed call, as well as calls
+// allowed with Allowed.CallThrough, to the method of the same name on the
+// delegate. Pass nil to stop delegating.
+//
+// You seldom need to call SetDelegate; if a test double has a field named
+// Delegate, FindMock (and therefore gomuti.Allow) makes its value the
+// delegate. Once you call SetDelegate, however, the Mock ignores that field.
+func (m Mock) SetDelegate(delegate interface{}) {
+	if m == nil {
+		panic(&UninitializedError{Type: "types.Mock", Method: "SetDelegate"})
+	}
+
+	l := m.lock()
+	l.Lock()
+	defer l.Unlock()
+	s := m.settings()
+	s.delegate, s.field, s.fieldType = reflect.ValueOf(delegate), weak.Pointer[byte]{}, nil
+	s.explicit = true
+}
+
+// Makes the Delegate field of a test double the delegate of the Mock, unless
+// SetDelegate was called. The caller must hold the lock for writing.
+func (m Mock) setDelegateField(f reflect.Value) {
+	s := m.settings()
+	if s.explicit {
+		return
+	} else if !f.CanAddr() {
+		s.delegate, s.field, s.fieldType = f, weak.Pointer[byte]{}, nil
+		return
+	}
+	s.delegate = reflect.Value{}
+	s.field = weak.Make((*byte)(f.Addr().UnsafePointer()))
+	s.fieldType = f.Type()
+}
+
+// Returns the delegate of a Mock, or nil if it has none. The caller must hold
+// the lock.
+func (m Mock) delegate() interface{} {
+	s := m.settings()
+	v := s.delegate
+	if s.fieldType != nil {
+		p := s.field.Value()
+		if p == nil {
+			return nil
+		}
+		v = reflect.NewAt(s.fieldType, unsafe.Pointer(p)).Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if v.IsNil() {
+			return nil
+		}
+	}
+	return v.Interface()
+}
+
+// SetClock specifies the Clock that the Mock uses to delay calls (see
+// Allowed.Delay). If it is nil, the Mock uses the real time.
+func (m Mock) SetClock(clock Clock) {
+	if m == nil {
+		panic(&UninitializedError{Type: "types.Mock", Method: "SetClock"})
+	}
+
+	l := m.lock()
+	l.Lock()
+	defer l.Unlock()
+	m.settings().clock = clock
+}
+
+// Returns the Mock's clock.
+func (m Mock) clock() Clock {
+	l := m.lock()
+	l.RLock()
+	defer l.RUnlock()
+	if s := m.settings(); s.clock != nil {
+		return s.clock
+	}
+	return systemClock{}
+}
+
+// Release unblocks every call to the Mock that is hanging (see Allowed.Hang).
+// Calls that hang later are not affected.
+func (m Mock) Release() {
+	if m == nil {
+		return
+	}
+
+	l := m.lock()
+	l.Lock()
+	defer l.Unlock()
+	if s := m.settings(); s.release != nil {
+		close(s.release)
+		s.release = nil
+	}
+}
+
+// Returns a channel that is closed when the Mock is next released. The caller
+// must hold the lock for writing.
+func (m Mock) released() <-chan struct{} {
+	s := m.settings()
+	if s.release == nil {
+		s.release = make(chan struct{})
+	}
+	return s.release
+}
@@ -1,78 +1,372 @@
 package types
 
 import (
+	"bytes"
 	"fmt"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
-type called struct {
+// RecordedCall is a method call that was observed by a Spy.
+type RecordedCall struct {
+	Method string
 	Params []interface{}
+	// Seq is a monotonically increasing sequence number that is shared by all
+	// spies; comparing the Seq of two calls tells you which happened first,
+	// even if they were observed by different test doubles.
+	Seq uint64
+	// Time at which the call was observed.
+	Time time.Time
+	// Source location of the code that called the test double, assuming
+	// that the double's method calls Observe directly.
+	File string
+	Line int
+	// ID of the goroutine that made the call.
+	Goroutine uint64
+
+	// The remaining fields are only set for calls that were observed with
+	// Begin, once the call has completed.
+	Completed bool
+	Results   []interface{}
+	Panicked  bool
+	Panic     interface{}
+	Duration  time.Duration
+}
+
+// String describes the call in a form suitable for debugging output.
+func (rc RecordedCall) String() string {
+	str := fmt.Sprintf("#%d %s(%s) at %s:%d (goroutine %d)",
+		rc.Seq, rc.Method, formatParams(rc.Params), filepath.Base(rc.File), rc.Line, rc.Goroutine)
+	if err, ok := rc.Panic.(error); ok && rc.Panicked {
+		str += fmt.Sprintf(" panicked with %q after %s", err.Error(), rc.Duration)
+	} else if rc.Panicked {
+		str += fmt.Sprintf(" panicked with %#v after %s", rc.Panic, rc.Duration)
+	} else if rc.Completed {
+		str += fmt.Sprintf(" returned (%s) after %s", formatParams(rc.Results), rc.Duration)
+	}
+	return str
+}
+
+// Determine whether this call's params satisfy some criteria. Params beyond
+// the number of criteria are not considered, unless the final criterion is a
+// RestMatcher. Panics with a MatcherError if a criterion fails.
+func (rc *RecordedCall) matches(criteria []Matcher) bool {
+	params := gatherRest(criteria, rc.Params)
+	if len(params) < len(criteria) {
+		return false
+	}
+	for i, c := range criteria {
+		succ, err := c.Match(params[i])
+		if err != nil {
+			panic(&MatcherError{Method: rc.Method, Index: i, Err: err})
+		}
+		if !succ {
+			return false
+		}
+	}
+	return true
 }
 
+// Most recently-assigned sequence number.
+var sequence uint64
+
 // Spy is a state container for recording information about calls made to a
 // test double.
-type Spy map[string][]called
+type Spy map[string][]RecordedCall
 
-// Observe records a method call.
+// Observe records a method call. It should be called directly by the method of
+// the test double, so that it can record the location of that method's caller.
 func (s Spy) Observe(method string, params ...interface{}) {
 	if s == nil {
-		panic("gomuti: must initialize Spy before calling Observe")
+		panic(&UninitializedError{Type: "types.Spy", Method: "Observe"})
+	}
+	_, file, line, _ := runtime.Caller(2)
+	s.observe(method, params, file, line)
+}
+
+// Begin records a method call, like Observe, and returns a Completion that
+// records the call's outcome. Test doubles that use Begin let you verify
+// the results, panics and durations of calls, not only their params:
+//
+//     func (m *MockAdder) Add(l, r int64) int64 {
+//       c := m.Spy.Init().Begin("Add", l, r)
+//       defer c.Recover()
+//       res := m.Mock.Call("Add", l, r)[0].(int64)
+//       c.Return(res)
+//       return res
+//     }
+func (s Spy) Begin(method string, params ...interface{}) *Completion {
+	if s == nil {
+		panic(&UninitializedError{Type: "types.Spy", Method: "Begin"})
+	}
+	_, file, line, _ := runtime.Caller(2)
+	return s.begin(method, params, file, line)
+}
+
+// Like Begin, but records the given location as the caller's.
+func (s Spy) begin(method string, params []interface{}, file string, line int) *Completion {
+	call := s.observe(method, params, file, line)
+	return &Completion{spy: s, method: method, seq: call.Seq, start: call.Time}
+}
+
+// Records a call to a method, made from the given location, and notifies
+// anyone who is waiting for it.
+func (s Spy) observe(method string, params []interface{}, file string, line int) RecordedCall {
+	call := RecordedCall{Method: method, Params: params, Time: time.Now(), Goroutine: goroutine(), File: file, Line: line}
+
+	l := s.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	call.Seq = atomic.AddUint64(&sequence, 1)
+	s[method] = append(s[method], call)
+	stateOf(s).publish(call)
+	s.broadcast()
+	return call
+}
+
+// Completion records the outcome of a call that a Spy observed with Begin.
+type Completion struct {
+	spy    Spy
+	method string
+	seq    uint64
+	start  time.Time
+}
+
+// Return records that the call returned the given results.
+func (c *Completion) Return(results ...interface{}) {
+	c.complete(func(rc *RecordedCall) {
+		rc.Results = results
+	})
+}
+
+// Panic records that the call panicked with the given reason.
+func (c *Completion) Panic(reason interface{}) {
+	c.complete(func(rc *RecordedCall) {
+		rc.Panicked, rc.Panic = true, reason
+	})
+}
+
+// Recover records a panic, if the call is panicking, then continues to panic.
+// It must be deferred by the method of the test double:
+//
+//     defer c.Recover()
+func (c *Completion) Recover() {
+	if r := recover(); r != nil {
+		c.Panic(r)
+		panic(r)
+	}
+}
+
+// Updates the recorded call, unless it has already completed.
+func (c *Completion) complete(update func(*RecordedCall)) {
+	d := time.Since(c.start)
+
+	l := c.spy.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	calls := c.spy[c.method]
+	for i := len(calls) - 1; i >= 0; i-- {
+		if calls[i].Seq == c.seq {
+			if !calls[i].Completed {
+				calls[i].Completed, calls[i].Duration = true, d
+				update(&calls[i])
+				c.spy.broadcast()
+			}
+			return
+		}
+	}
+}
+
+// Reset forgets every call that the spy has observed, so that it can be
+// reused by another test, and closes every channel returned by Subscribe.
+func (s Spy) Reset() {
+	l := s.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	for method := range s {
+		delete(s, method)
+	}
+	stateOf(s).unsubscribeAll()
+}
+
+// Filter returns a copy of the spy that contains only the recorded calls for
+// which keep returns true.
+func (s Spy) Filter(keep func(RecordedCall) bool) Spy {
+	if s == nil {
+		panic(&UninitializedError{Type: "types.Spy", Method: "Filter"})
+	}
+
+	l := s.lock()
+	l.RLock()
+	defer l.RUnlock()
+
+	res := Spy{}
+	for method, calls := range s {
+		for _, c := range calls {
+			if keep(c) {
+				res[method] = append(res[method], c)
+			}
+		}
 	}
+	return res
+}
 
-	events := s[method]
-	events = append(events, called{Params: params})
-	s[method] = events
+// Returns the ID of the current goroutine, or 0 if it cannot be determined.
+func goroutine() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	// The stack trace begins with "goroutine 123 [running]:"
+	fields := bytes.Fields(buf)
+	if len(fields) < 2 {
+		return 0
+	}
+	id, _ := strconv.ParseUint(string(fields[1]), 10, 64)
+	return id
+}
+
+// Init initializes a nil Spy and returns its value. Unlike a plain nil check
+// and assignment, it is safe to call from several goroutines at once, which
+// makes it suitable for generated test doubles:
+//
+//     m.Spy.Init().Observe("Add", l, r)
+func (s *Spy) Init() Spy {
+	fields.RLock()
+	spy := *s
+	fields.RUnlock()
+	if spy != nil {
+		return spy
+	}
+
+	fields.Lock()
+	defer fields.Unlock()
+	if *s == nil {
+		*s = Spy{}
+	}
+	return *s
 }
 
 // Count returns the number of times a method was called that matched the given
-// criteria.
+// criteria. Any captors among the criteria are set to the params of the
+// matching calls.
 func (s Spy) Count(method string, criteria ...Matcher) int {
 	if s == nil {
-		panic("gomuti: must initialize Spy before calling Count")
+		panic(&UninitializedError{Type: "types.Spy", Method: "Count"})
 	}
 
-	events := s[method]
-	res := 0
+	return len(s.matching(method, criteria))
+}
+
+// Sequence returns the sequence numbers of every recorded call to a method
+// that matched the given criteria, in the order the calls were observed. Any
+// captors among the criteria are set to the params of the matching calls.
+func (s Spy) Sequence(method string, criteria ...Matcher) []uint64 {
+	if s == nil {
+		panic(&UninitializedError{Type: "types.Spy", Method: "Sequence"})
+	}
+
+	events := s.matching(method, criteria)
+	res := make([]uint64, len(events))
+	for i := range events {
+		res[i] = events[i].Seq
+	}
+	return res
+}
+
+// Returns every recorded call to a method that matched the given criteria, and
+// sets any captors among the criteria to the params of those calls.
+func (s Spy) matching(method string, criteria []Matcher) []RecordedCall {
+	l := s.lock()
+	l.RLock()
+	defer l.RUnlock()
+	return s.match(method, criteria)
+}
 
-	for _, ev := range events {
-		if len(ev.Params) < len(criteria) {
-			continue
+// Like matching, but the caller must hold the lock.
+func (s Spy) match(method string, criteria []Matcher) []RecordedCall {
+	var res []RecordedCall
+	for _, event := range s[method] {
+		if event.matches(criteria) {
+			res = append(res, event)
 		}
-		failed := false
-		for i, c := range criteria {
-			succ, err := c.Match(ev.Params[i])
-			if err != nil {
-				panic(err)
-			}
-			if !succ {
-				failed = true
-				break
+	}
+
+	for i, c := range criteria {
+		if cap, ok := c.(capturer); ok {
+			values := make([]interface{}, len(res))
+			for j := range res {
+				values[j] = res[j].Params[i]
 			}
-		}
-		if !failed {
-			res++
+			cap.set(values)
 		}
 	}
+	return res
+}
+
+// Calls returns every call to a method that the spy has observed, in the order
+// the calls were observed.
+func (s Spy) Calls(method string) CallHistory {
+	if s == nil {
+		panic(&UninitializedError{Type: "types.Spy", Method: "Calls"})
+	}
+
+	l := s.lock()
+	l.RLock()
+	defer l.RUnlock()
+
+	return append(CallHistory{}, s[method]...)
+}
+
+// AllCalls returns every call that the spy has observed, regardless of method,
+// in the order the calls were observed.
+func (s Spy) AllCalls() CallHistory {
+	if s == nil {
+		panic(&UninitializedError{Type: "types.Spy", Method: "AllCalls"})
+	}
+
+	l := s.lock()
+	l.RLock()
+	defer l.RUnlock()
+
+	res := CallHistory{}
+	for _, events := range s {
+		res = append(res, events...)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Seq < res[j].Seq })
 
 	return res
 }
 
 // ClosestMatch returns the parameters of the recorded call that most closely
 // matches the given criteria, or nil if the method was never called at all.
+// As with Count, a final RestMatcher is matched against the remaining params
+// as a whole.
 func (s Spy) ClosestMatch(method string, criteria ...Matcher) []interface{} {
 	if s == nil {
-		panic("gomuti: must initialize Spy before calling ClosestMatch")
+		panic(&UninitializedError{Type: "types.Spy", Method: "ClosestMatch"})
 	}
 
+	l := s.lock()
+	l.RLock()
+	defer l.RUnlock()
+
 	var best []interface{}
 	var bestCount int
 
 	for _, call := range s[method] {
 		count := 0
+		params := gatherRest(criteria, call.Params)
 		for i, crit := range criteria {
-			if len(call.Params) > i {
-				if ok, _ := crit.Match(call.Params[i]); ok {
+			if len(params) > i {
+				if ok, _ := crit.Match(params[i]); ok {
 					count++
 				}
 			}
@@ -113,7 +407,7 @@ func FindSpy(v reflect.Value) Spy {
 		// The real McCoy! (Or a pointer to it.)
 		if ptr {
 			if v.IsNil() {
-				panic(fmt.Sprintf("gomuti.Allow: must initialize %s before calling", v.Type().String()))
+				panic(&UninitializedError{Type: v.Type().String()})
 			}
 			return reflect.Indirect(v).Interface().(Spy)
 		}
@@ -128,29 +422,34 @@ func FindSpy(v reflect.Value) Spy {
 				var spy Spy
 				if ptr {
 					if v.IsNil() {
-						panic(fmt.Sprintf("gomuti: must initialize *%s before calling", t.Name()))
+						panic(&UninitializedError{Type: v.Type().String()})
 					}
 					v = reflect.Indirect(v)
 					if !v.IsValid() {
-						panic(fmt.Sprintf("gomuti: must initialize %s.%s before calling", t.Name(), sf.Name))
+						panic(&UninitializedError{Type: t.String(), Field: sf.Name})
 					}
 					f := v.Field(i)
 					if !f.CanInterface() {
-						panic(fmt.Sprintf("gomuti: cannot work with unexported field %s of %s; change it to %s", sf.Name, t.String(), strings.Title(sf.Name)))
+						misuse("FindSpy", "cannot work with unexported field %s of %s; change it to %s", sf.Name, t.String(), strings.Title(sf.Name))
 					}
 				}
-				spy = v.Field(i).Interface().(Spy)
-				if spy == nil {
-					if ptr {
+				spy = func() Spy {
+					fields.Lock()
+					defer fields.Unlock()
+					spy := v.Field(i).Interface().(Spy)
+					if spy == nil && ptr {
 						spy = Spy{}
 						reflect.Indirect(v).Field(i).Set(reflect.ValueOf(spy))
-					} else {
-						panic(fmt.Sprintf("gomuti: must pass a pointer to %s or initialize its .Spy before calling", t.String()))
 					}
+					return spy
+				}()
+				if spy == nil {
+					panic(&UninitializedError{Type: t.String(), Field: sf.Name})
 				}
 				return spy
 			}
 		}
 	}
-	panic(fmt.Sprintf("gomuti: don't know how to spy on %s", v.Type().Name()))
+	misuse("FindSpy", "don't know how to spy on %s", v.Type().String())
+	return nil
 }
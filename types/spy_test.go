@@ -33,10 +33,10 @@ var _ = Describe("Spy", func() {
 		checker := func() {
 			r := recover()
 
-			str, _ := r.(string)
-			Expect(str).NotTo(BeNil())
+			err, _ := r.(*types.UninitializedError)
+			Expect(err).NotTo(BeNil())
 
-			Expect(str).To(MatchRegexp("^gomuti:"))
+			Expect(err.Error()).To(MatchRegexp("^gomuti:"))
 			panic(r)
 		}
 
@@ -73,7 +73,7 @@ var _ = Describe("Spy", func() {
 		s.Observe("Bar", "puppies", 7)
 		s.Observe("Bar", "puppies", 7.0)
 
-		Expect(s).To(HaveCall("Bar").Times(2))
+		Expect(s).To(HaveCall("Bar").Times(3))
 		Expect(s).To(HaveCall("Bar").With("shenanigans", true).Times(1))
 		Expect(s).To(HaveCall("Bar").With("hedgehogs", true).Never())
 		Expect(s).To(HaveCall("Bar").With("puppies", 7).Times(1))
@@ -92,6 +92,62 @@ var _ = Describe("Spy", func() {
 		Expect(s).To(HaveCall("Bar").With(&t0).Times(2))
 	})
 
+	Context("call history", func() {
+		It("records details of each call", func() {
+			start := time.Now()
+			double := &hammered{}
+			double.Add(1, 2)
+			double.Add(3, 4)
+
+			calls := double.Spy.Calls("Add")
+			Expect(calls).To(HaveLen(2))
+			Expect(calls[0].Params).To(Equal([]interface{}{1, 2}))
+			Expect(calls[0].Seq).To(BeNumerically("<", calls[1].Seq))
+			Expect(calls[0].Time).NotTo(BeTemporally("<", start))
+			Expect(calls[0].File).To(HaveSuffix("spy_test.go"))
+			Expect(calls[0].Line).To(BeNumerically(">", 0))
+			Expect(calls[0].Goroutine).NotTo(BeZero())
+			Expect(calls[0].String()).To(MatchRegexp(`^#\d+ Add\(1, 2\) at spy_test.go:\d+ \(goroutine \d+\)$`))
+		})
+
+		It("records outcomes of calls", func() {
+			s.Begin("Foo", 1).Return(2, nil)
+			Expect(func() {
+				c := s.Begin("Foo", 3)
+				defer c.Recover()
+				panic("oops")
+			}).To(PanicWith("oops"))
+			s.Begin("Foo", 4)
+
+			calls := s.Calls("Foo")
+			Expect(calls[0].Completed).To(BeTrue())
+			Expect(calls[0].Results).To(Equal([]interface{}{2, nil}))
+			Expect(calls[0].Duration).To(BeNumerically(">", 0))
+			Expect(calls[1].Panicked).To(BeTrue())
+			Expect(calls[1].Panic).To(Equal("oops"))
+			Expect(calls[2].Completed).To(BeFalse())
+		})
+
+		It("filters calls", func() {
+			s.Observe("Foo", 1)
+			s.Observe("Bar", 2)
+			s.Observe("Foo", 3)
+			mid := time.Now()
+			time.Sleep(time.Millisecond)
+			s.Observe("Baz", 4)
+
+			all := s.AllCalls()
+			Expect(all).To(HaveLen(4))
+			Expect(all.Method("Foo", "Baz")).To(HaveLen(3))
+			Expect(all.Matching(BeNumerically(">", 1))).To(HaveLen(3))
+			Expect(all.Method("Foo").Matching(Equal(3))).To(HaveLen(1))
+			Expect(all.Since(mid)).To(HaveLen(1))
+			Expect(all.OnGoroutine(all[0].Goroutine)).To(HaveLen(4))
+			Expect(all.OnGoroutine(0)).To(BeEmpty())
+			Expect(all.Filter(func(c types.RecordedCall) bool { return c.Method == "Bar" })[0].Params).To(Equal([]interface{}{2}))
+		})
+	})
+
 	PIt("matches partial parameter lists")
 
 	PIt("has a useful failure message")
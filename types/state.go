@@ -0,0 +1,109 @@
+package types
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+	"unsafe"
+	"weak"
+)
+
+// Mock and Spy are map types (so that test doubles can contain them without
+// any initialization ceremony) and therefore cannot carry fields of their own.
+// Instead, each Mock and Spy has a state that is kept in a side table, keyed
+// by the identity of its map, which is shared by every copy of the map.
+//
+// The table only refers to maps weakly: once a map becomes unreachable, its
+// state is dropped. A state must therefore never refer to its own map, or to
+// a test double that contains the map.
+type state struct {
+	// Guards the contents of the map and the rest of the state, so that test
+	// doubles can be programmed, called and inspected from several goroutines
+	// at once.
+	//
+	// The lock is never held while running user-supplied behaviors (Do
+	// functions and panics), but it IS held while running parameter matchers,
+	// captors and tiebreaks; these must not call the test double that they
+	// belong to.
+	mutex sync.RWMutex
+
+	// Mock only
+	settings settings
+
+	// Spy only: closed when the Spy next observes or completes a call
+	changed chan struct{}
+	// Spy only: the channels returned by Subscribe
+	subs []*subscription
+}
+
+// An entry of the side table: the state of a map, and a weak pointer to the
+// map that tells whether the entry is still current. When a map is collected,
+// another one may later be allocated at the same address.
+type stateEntry struct {
+	ref   weak.Pointer[byte]
+	state *state
+}
+
+var (
+	statesMutex sync.Mutex
+	states      = map[uintptr]*stateEntry{}
+)
+
+// Returns the state of a Mock or Spy, creating it if necessary. A nil map gets
+// a state of its own that is not remembered.
+func stateOf(m interface{}) *state {
+	p := (*byte)(reflect.ValueOf(m).UnsafePointer())
+	if p == nil {
+		return &state{}
+	}
+	key := uintptr(unsafe.Pointer(p))
+
+	statesMutex.Lock()
+	defer statesMutex.Unlock()
+
+	if e, ok := states[key]; ok && e.ref.Value() == p {
+		return e.state
+	}
+	e := &stateEntry{ref: weak.Make(p), state: &state{}}
+	states[key] = e
+	runtime.AddCleanup(p, dropState, stateKey{key, e.ref})
+	return e.state
+}
+
+// Identifies an entry of the side table for dropState.
+type stateKey struct {
+	addr uintptr
+	ref  weak.Pointer[byte]
+}
+
+// Removes the entry of a map that has been collected, unless it has already
+// been replaced by the entry of a newer map at the same address, and closes
+// the subscriptions of a collected Spy.
+func dropState(k stateKey) {
+	statesMutex.Lock()
+	e, ok := states[k.addr]
+	if ok && e.ref == k.ref {
+		delete(states, k.addr)
+	}
+	statesMutex.Unlock()
+
+	if ok && e.ref == k.ref {
+		e.state.mutex.Lock()
+		defer e.state.mutex.Unlock()
+		e.state.unsubscribeAll()
+	}
+}
+
+// Guards the Mock and Spy fields of test doubles while FindMock, FindSpy and
+// Spy.Init initialize them.
+var fields sync.RWMutex
+
+// Returns the lock of a Mock.
+func (m Mock) lock() *sync.RWMutex {
+	return &stateOf(m).mutex
+}
+
+// Returns the lock of a Spy.
+func (s Spy) lock() *sync.RWMutex {
+	return &stateOf(s).mutex
+}
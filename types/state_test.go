@@ -0,0 +1,46 @@
+package types
+
+import (
+	"reflect"
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("state", func() {
+	entries := func() int {
+		runtime.GC()
+		statesMutex.Lock()
+		defer statesMutex.Unlock()
+		return len(states)
+	}
+
+	It("is shared by copies of a map", func() {
+		m := Mock{}
+		copied := m
+		Expect(stateOf(copied)).To(BeIdenticalTo(stateOf(m)))
+		Expect(stateOf(Mock{})).NotTo(BeIdenticalTo(stateOf(m)))
+	})
+
+	It("is dropped when its map is collected", func() {
+		before := entries()
+		for i := 0; i < 100; i++ {
+			stateOf(Mock{"Foo": nil})
+			stateOf(Spy{})
+		}
+		Eventually(entries).Should(BeNumerically("<=", before))
+	})
+
+	It("does not keep test doubles with a Delegate alive", func() {
+		type partial struct {
+			Mock     Mock
+			Delegate interface{}
+		}
+		before := entries()
+		for i := 0; i < 100; i++ {
+			FindMock(reflect.ValueOf(&partial{}))
+		}
+		Eventually(entries).Should(BeNumerically("<=", before))
+	})
+})
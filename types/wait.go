@@ -0,0 +1,179 @@
+package types
+
+import (
+	"sync"
+	"time"
+)
+
+// Returns a channel that is closed when the Spy next observes or completes a
+// call. The caller must hold the lock.
+func (s Spy) changed() <-chan struct{} {
+	st := stateOf(s)
+	if st.changed == nil {
+		st.changed = make(chan struct{})
+	}
+	return st.changed
+}
+
+// Wakes everyone who is waiting for a change to the Spy. The caller must hold
+// the lock for writing.
+func (s Spy) broadcast() {
+	st := stateOf(s)
+	if st.changed != nil {
+		close(st.changed)
+		st.changed = nil
+	}
+}
+
+// WaitFor blocks until the spy has observed at least count calls to a method
+// that match the given criteria, or until the timeout elapses. It returns the
+// matching calls, together with a WaitTimeoutError if there were too few.
+// Any captors among the criteria are set to the params of the matching calls.
+//
+// Use it to wait for code that calls a test double from another goroutine,
+// without sleeping:
+//
+//     go subject.Sync()
+//     calls, err := double.Spy.WaitFor("Save", 2, time.Second, Anything())
+//     Expect(err).NotTo(HaveOccurred())
+//     Expect(calls[1].Params[0]).To(Equal("second"))
+func (s Spy) WaitFor(method string, count int, timeout time.Duration, criteria ...Matcher) (CallHistory, error) {
+	if s == nil {
+		panic(&UninitializedError{Type: "types.Spy", Method: "WaitFor"})
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		l := s.lock()
+		l.Lock()
+		res := s.match(method, criteria)
+		wake := s.changed()
+		l.Unlock()
+
+		if len(res) >= count {
+			return res, nil
+		}
+		select {
+		case <-wake:
+		case <-timer.C:
+			return res, &WaitTimeoutError{Method: method, Want: count, Got: len(res), Timeout: timeout}
+		}
+	}
+}
+
+// Subscribe returns a channel that receives every call to a method that the
+// spy observes from now on, in the order observed. Calls are delivered when
+// they begin, so calls observed with Begin are not yet Completed.
+//
+// The spy never waits for subscribers; calls queue up until they are received.
+// Call Unsubscribe when you no longer need the channel. Reset closes every
+// subscription, as does the collection of a Spy that is no longer used.
+func (s Spy) Subscribe(method string) <-chan RecordedCall {
+	if s == nil {
+		panic(&UninitializedError{Type: "types.Spy", Method: "Subscribe"})
+	}
+
+	sub := &subscription{
+		method: method,
+		out:    make(chan RecordedCall),
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go sub.pump()
+
+	l := s.lock()
+	l.Lock()
+	defer l.Unlock()
+	st := stateOf(s)
+	st.subs = append(st.subs, sub)
+	return sub.out
+}
+
+// Unsubscribe stops delivering calls to a channel returned by Subscribe, and
+// closes the channel. Calls that were queued for the channel, but not yet
+// received, are discarded.
+func (s Spy) Unsubscribe(ch <-chan RecordedCall) {
+	l := s.lock()
+	l.Lock()
+	defer l.Unlock()
+
+	st := stateOf(s)
+	for i, sub := range st.subs {
+		if sub.out == ch {
+			st.subs = append(st.subs[:i], st.subs[i+1:]...)
+			close(sub.done)
+			return
+		}
+	}
+}
+
+// Queues a call for every subscription to its method. The caller must hold
+// the lock.
+func (st *state) publish(call RecordedCall) {
+	for _, sub := range st.subs {
+		if sub.method == call.Method {
+			sub.push(call)
+		}
+	}
+}
+
+// Closes every subscription, so that their goroutines exit. The caller must
+// hold the lock.
+func (st *state) unsubscribeAll() {
+	for _, sub := range st.subs {
+		close(sub.done)
+	}
+	st.subs = nil
+}
+
+// A channel returned by Subscribe, with an unbounded queue of calls that
+// have yet to be received from it.
+type subscription struct {
+	method string
+	out    chan RecordedCall
+
+	mutex sync.Mutex
+	queue []RecordedCall
+	// signalled when the queue grows
+	wake chan struct{}
+	// closed by Unsubscribe
+	done chan struct{}
+}
+
+func (sub *subscription) push(call RecordedCall) {
+	sub.mutex.Lock()
+	sub.queue = append(sub.queue, call)
+	sub.mutex.Unlock()
+
+	select {
+	case sub.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Delivers queued calls to the channel until Unsubscribe, then closes it.
+func (sub *subscription) pump() {
+	defer close(sub.out)
+	for {
+		sub.mutex.Lock()
+		if len(sub.queue) == 0 {
+			sub.mutex.Unlock()
+			select {
+			case <-sub.wake:
+				continue
+			case <-sub.done:
+				return
+			}
+		}
+		call := sub.queue[0]
+		sub.queue = sub.queue[1:]
+		sub.mutex.Unlock()
+
+		select {
+		case sub.out <- call:
+		case <-sub.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package types_test
+
+import (
+	"runtime"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/xeger/gomuti"
+	"github.com/xeger/gomuti/types"
+)
+
+var _ = Describe("Spy", func() {
+	var double *hammered
+
+	BeforeEach(func() {
+		double = &hammered{Mock: types.Mock{}}
+		Allow(double).Call("Add").Return(0)
+	})
+
+	Context("WaitFor", func() {
+		It("returns at once if enough calls were observed", func() {
+			double.Add(1, 2)
+			calls, err := double.Spy.Init().WaitFor("Add", 1, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(calls).To(HaveLen(1))
+		})
+
+		It("waits for calls from other goroutines", func() {
+			go func(double *hammered) {
+				for i := 0; i < 5; i++ {
+					double.Add(i, i)
+				}
+			}(double)
+
+			c := &types.CaptorOf[int]{}
+			calls, err := double.Spy.Init().WaitFor("Add", 2, 5*time.Second, c, BeNumerically(">=", 3))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(calls).To(HaveLen(2))
+			Expect(c.All()).To(Equal([]int{3, 4}))
+		})
+
+		It("times out if too few calls match", func() {
+			double.Add(1, 2)
+			calls, err := double.Spy.Init().WaitFor("Add", 2, 10*time.Millisecond)
+			Expect(calls).To(HaveLen(1))
+			Expect(err).To(MatchError("gomuti: timed out after 10ms waiting for 2 matching calls to Add; observed 1"))
+			Expect(err).To(BeAssignableToTypeOf(&types.WaitTimeoutError{}))
+		})
+	})
+
+	Context("Subscribe", func() {
+		It("delivers calls to a method in order", func() {
+			ch := double.Spy.Init().Subscribe("Add")
+			double.Spy.Observe("Subtract", 1, 1)
+			go func(double *hammered) {
+				for i := 0; i < 100; i++ {
+					double.Add(i, 0)
+				}
+			}(double)
+
+			for i := 0; i < 100; i++ {
+				var call types.RecordedCall
+				Eventually(ch).Should(Receive(&call))
+				Expect(call.Method).To(Equal("Add"))
+				Expect(call.Params).To(Equal([]interface{}{i, 0}))
+			}
+			Consistently(ch).ShouldNot(Receive())
+		})
+
+		It("closes the channel on Unsubscribe", func() {
+			ch := double.Spy.Init().Subscribe("Add")
+			double.Add(1, 1)
+			double.Spy.Unsubscribe(ch)
+			Eventually(ch).Should(BeClosed())
+			double.Add(2, 2)
+		})
+
+		It("closes every channel on Reset", func() {
+			spy := double.Spy.Init()
+			before := runtime.NumGoroutine()
+			chs := []<-chan types.RecordedCall{}
+			for i := 0; i < 10; i++ {
+				chs = append(chs, spy.Subscribe("Add"))
+			}
+			double.Add(1, 1)
+			spy.Reset()
+
+			for _, ch := range chs {
+				Eventually(ch).Should(BeClosed())
+			}
+			Eventually(runtime.NumGoroutine).Should(BeNumerically("<=", before))
+			Expect(spy.AllCalls()).To(BeEmpty())
+			Expect(spy).To(BeEmpty())
+		})
+
+		It("closes every channel when the spy is collected", func() {
+			before := runtime.NumGoroutine()
+			for i := 0; i < 10; i++ {
+				types.Spy{}.Subscribe("Add")
+			}
+			Eventually(func() int {
+				runtime.GC()
+				return runtime.NumGoroutine()
+			}).Should(BeNumerically("<=", before))
+		})
+	})
+})